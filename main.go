@@ -8,6 +8,7 @@ import (
 func main() {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", ms.MakeMazeResponse)
+	mux.HandleFunc("/stream", ms.MakeStreamResponse)
 
 	port := "3000"
 	http.ListenAndServe(":"+port, mux)
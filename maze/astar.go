@@ -0,0 +1,168 @@
+package maze
+
+import (
+	"container/heap"
+	"math"
+)
+
+// astar finds the cheapest path from startIndex to the goal, searching the nodes the heuristic guesses are closest to the goal first.
+// It returns the same values as bfs.
+func astar(m *maze, startIndex int, heuristic string) (exists bool, path *[][]int, solution *[]int, err error) {
+	guess, err := m.makeHeuristic(heuristic)
+	if err != nil {
+		return false, nil, nil, err
+	}
+	pathOut := make([]int, 0)
+	solutionOut := make([]int, 0)
+	visited := make([]bool, len(m.g.nodes), len(m.g.nodes))
+	parents := make([]int, len(m.g.nodes), len(m.g.nodes))
+	costs := make([]int, len(m.g.nodes), len(m.g.nodes))
+	for i := range costs {
+		costs[i] = -1
+	}
+
+	queue := &priorityQueue{{index: startIndex, priority: guess(startIndex)}}
+	parents[startIndex] = -1
+	costs[startIndex] = 0
+
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(queueItem)
+		// A node can be queued more than once if a cheaper way to it is found later
+		if visited[current.index] {
+			continue
+		}
+		visited[current.index] = true
+		if current.index == m.goal {
+			exists = true
+			break
+		}
+		pathOut = append(pathOut, current.index)
+
+		for _, adj := range m.g.nodes[current.index].neighbors {
+			next := adj.n.index
+			cost := current.cost + adj.weight
+			if !visited[next] && (costs[next] == -1 || cost < costs[next]) {
+				costs[next] = cost
+				parents[next] = current.index
+				// The priority is the cost so far plus the guess of the cost left to the goal
+				heap.Push(queue, queueItem{index: next, cost: cost, priority: float64(cost) + guess(next)})
+			}
+		}
+	}
+
+	// Backtrack through parents to find the cheapest path.
+	if exists {
+		// Start at the goal node.
+		i := m.goal
+		for i != -1 {
+			solutionOut = append(solutionOut, i)
+			i = parents[i]
+		}
+	}
+	return exists, &[][]int{pathOut}, &solutionOut, nil
+}
+
+// makeHeuristic returns a function that guesses the cost of getting from a node to the goal.
+// The distance is measured in nodes, and every node costs at least as much as the cheapest node in the maze.
+// The guess is never more than the real cost, so A* always finds the cheapest path.
+func (m *maze) makeHeuristic(heuristic string) (func(index int) float64, error) {
+	var distance func(index1 int, index2 int) float64
+	switch heuristic {
+	case HEURISTIC_MANHATTAN, "":
+		distance = m.manhattan
+	case HEURISTIC_EUCLIDEAN:
+		distance = m.euclidean
+	case HEURISTIC_ZERO:
+		return func(index int) float64 {
+			return 0
+		}, nil
+	default:
+		return nil, mkErr("invalid heuristic")
+	}
+	// Portals and edges that wrap join nodes that are far apart, so no distance is a safe guess
+	if len(m.portals) > 0 || m.wrapRows || m.wrapCols {
+		return func(index int) float64 {
+			return 0
+		}, nil
+	}
+	minCost := math.MaxInt
+	for _, n := range m.g.nodes {
+		if n.cost < minCost {
+			minCost = n.cost
+		}
+	}
+	// Nodes in polar mazes get wider further out, so distances are shrunk until no two neighbors are more than 1 apart
+	scale := 1.0
+	if m.shape == SHAPE_POLAR {
+		for index := range m.g.nodes {
+			for _, n := range m.gridSides(index) {
+				if n != -1 {
+					scale = math.Max(scale, distance(index, n))
+				}
+			}
+		}
+	}
+	// A tunnel goes under a node, so one step through it moves 2 nodes
+	if len(m.crossings) > 0 {
+		scale *= 2
+	}
+	return func(index int) float64 {
+		return float64(minCost) * distance(index, m.goal) / scale
+	}, nil
+}
+
+// manhattan returns the number of steps between two nodes if there were no walls.
+// Hex nodes can step diagonally, so they are measured in the cube coordinates of a hex grid.
+// Each step between triangles changes either the row or the column, so they are measured in rows and columns.
+// Polar nodes are measured by their positions, which makes outer rings further apart than they are (see makeHeuristic).
+func (m *maze) manhattan(index1 int, index2 int) float64 {
+	row1, col1 := getMazeCoords(m, index1)
+	row2, col2 := getMazeCoords(m, index2)
+	switch m.shape {
+	case SHAPE_HEX:
+		// Odd rows are shifted right, so a column leans a node to the right every two rows
+		x1, x2 := col1-(row1-row1%2)/2, col2-(row2-row2%2)/2
+		dx, dz := x1-x2, row1-row2
+		return math.Max(math.Abs(float64(dx)), math.Max(math.Abs(float64(dz)), math.Abs(float64(dx+dz))))
+	case SHAPE_TRIANGLE:
+		return math.Abs(float64(row1-row2)) + math.Abs(float64(col1-col2))
+	}
+	p, q := m.position(index1), m.position(index2)
+	return math.Abs(p[0]-q[0]) + math.Abs(p[1]-q[1]) + math.Abs(p[2]-q[2])
+}
+
+// euclidean returns the straight line distance between the centers of two nodes.
+func (m *maze) euclidean(index1 int, index2 int) float64 {
+	p, q := m.position(index1), m.position(index2)
+	return math.Sqrt(math.Pow(p[0]-q[0], 2) + math.Pow(p[1]-q[1], 2) + math.Pow(p[2]-q[2], 2))
+}
+
+// position returns the x, y and z coordinates of the center of the node at index.
+// The centers of nodes next to each other are 1 apart, except in polar mazes, where nodes in outer rings can be further apart (see makeHeuristic).
+// Only mazes with levels use z.
+func (m *maze) position(index int) []float64 {
+	row, col := getMazeCoords(m, index)
+	switch m.shape {
+	case SHAPE_HEX:
+		// Odd rows are shifted right by half a node, and rows overlap by a quarter of a hexagon
+		return []float64{float64(col) + 0.5*float64(row%2), float64(row) * math.Sqrt(3) / 2, 0}
+	case SHAPE_TRIANGLE:
+		// Triangles with sides of √3 have centers 1 apart, and the center is a third of the way from the base
+		side := math.Sqrt(3)
+		height := side * math.Sqrt(3) / 2
+		y := float64(row)*height + height/3
+		if TrianglePointsUp(row, col) {
+			y += height / 3
+		}
+		return []float64{float64(col) * side / 2, y, 0}
+	case SHAPE_POLAR:
+		if row == 0 {
+			return []float64{0, 0, 0}
+		}
+		// Rings are 1 apart, which puts the first ring 1 from the center
+		angle := 2 * math.Pi * (float64(col) + 0.5) / float64(m.ringSize(row))
+		radius := float64(row)
+		return []float64{radius * math.Cos(angle), radius * math.Sin(angle), 0}
+	}
+	return []float64{float64(col), float64(row % m.height), float64(row / m.height)}
+}
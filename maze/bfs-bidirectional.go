@@ -0,0 +1,103 @@
+package maze
+
+// bfsBidirectional searches from the start and the goal at the same time, a whole layer of one side at a time, until the two searches meet.
+// It returns:
+// - a boolean which is true if the goal can be reached
+// - a slice with the nodes searched from the start and a slice with the nodes searched from the goal, in the order they were visited
+// - a solution slice of indexes, starting with the goal and ending with the start like bfs
+// The search from the goal follows edges backwards, so it works with one-way passages.
+func bfsBidirectional(g *graph, startIndex int, goalIndex int) (exists bool, paths *[][]int, solution *[]int) {
+	fromStart := make([]int, 0)
+	fromGoal := make([]int, 0)
+	solutionOut := make([]int, 0)
+	if startIndex == goalIndex {
+		solutionOut = append(solutionOut, goalIndex)
+		return true, &[][]int{fromStart, fromGoal}, &solutionOut
+	}
+
+	// The search from the goal needs to know which nodes have an edge into each node
+	incoming := make([][]int, len(g.nodes))
+	for _, n := range g.nodes {
+		for _, adj := range n.neighbors {
+			incoming[adj.n.index] = append(incoming[adj.n.index], n.index)
+		}
+	}
+	outgoing := func(index int) []int {
+		out := make([]int, len(g.nodes[index].neighbors))
+		for i, adj := range g.nodes[index].neighbors {
+			out[i] = adj.n.index
+		}
+		return out
+	}
+
+	// Parents point back towards the start for one search, and towards the goal for the other.
+	// -1 is the root of a search, and -2 has not been visited.
+	startParents := make([]int, len(g.nodes))
+	goalParents := make([]int, len(g.nodes))
+	startDistances := make([]int, len(g.nodes))
+	goalDistances := make([]int, len(g.nodes))
+	for i := range g.nodes {
+		startParents[i] = -2
+		goalParents[i] = -2
+	}
+	startParents[startIndex] = -1
+	goalParents[goalIndex] = -1
+	startLayer := []int{startIndex}
+	goalLayer := []int{goalIndex}
+
+	meeting := -1
+	for meeting == -1 && len(startLayer) > 0 && len(goalLayer) > 0 {
+		// Search a layer of the side with fewer nodes waiting, so neither side grows much bigger than the other
+		if len(startLayer) <= len(goalLayer) {
+			startLayer, meeting = bfsLayer(startLayer, outgoing, startParents, startDistances, goalParents, goalDistances, &fromStart)
+		} else {
+			goalLayer, meeting = bfsLayer(goalLayer, func(index int) []int { return incoming[index] }, goalParents, goalDistances, startParents, startDistances, &fromGoal)
+		}
+	}
+	if meeting == -1 {
+		return false, &[][]int{fromStart, fromGoal}, &solutionOut
+	}
+
+	// Backtrack from the meeting point to the goal, then reverse it so it starts with the goal
+	for i := meeting; i != -1; i = goalParents[i] {
+		solutionOut = append(solutionOut, i)
+	}
+	for i, j := 0, len(solutionOut)-1; i < j; i, j = i+1, j-1 {
+		solutionOut[i], solutionOut[j] = solutionOut[j], solutionOut[i]
+	}
+	// Splice on the way back from the meeting point to the start
+	for i := startParents[meeting]; i != -1; i = startParents[i] {
+		solutionOut = append(solutionOut, i)
+	}
+
+	// The goal is drawn over by the solution, like in bfs
+	if len(fromGoal) > 0 && fromGoal[0] == goalIndex {
+		fromGoal = fromGoal[1:]
+	}
+	return true, &[][]int{fromStart, fromGoal}, &solutionOut
+}
+
+// bfsLayer visits every node in layer for one side of bfsBidirectional, and returns the next layer.
+// If the other side has already reached a node next to this layer, it also returns the node where the searches meet on the shortest path, or -1 if they haven't met.
+// Every node in the layer is visited before checking for a meeting, so the shortest of the meetings in this layer is picked.
+func bfsLayer(layer []int, next func(index int) []int, parents []int, distances []int, otherParents []int, otherDistances []int, pathOut *[]int) ([]int, int) {
+	nextLayer := make([]int, 0)
+	meeting := -1
+	shortest := -1
+	for _, index := range layer {
+		*pathOut = append(*pathOut, index)
+		for _, n := range next(index) {
+			if parents[n] != -2 {
+				continue
+			}
+			parents[n] = index
+			distances[n] = distances[index] + 1
+			nextLayer = append(nextLayer, n)
+			if otherParents[n] != -2 && (shortest == -1 || distances[n]+otherDistances[n] < shortest) {
+				shortest = distances[n] + otherDistances[n]
+				meeting = n
+			}
+		}
+	}
+	return nextLayer, meeting
+}
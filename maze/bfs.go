@@ -2,6 +2,7 @@ package maze
 
 import (
 	"context"
+	"runtime"
 	"sync"
 	"time"
 )
@@ -168,6 +169,9 @@ func bfsMultithreaded(g *graph, goalVal int, startIndex int, maxThreads int) (bo
 				close(parentIn)
 				break
 			}
+			// Yield so the senders can run. With one core, this loop would otherwise keep it until the scheduler preempts it,
+			// and the senders would get so little time that the search hits the timeout before it finds the goal.
+			runtime.Gosched()
 		} else {
 			pair := <-childOut
 
@@ -0,0 +1,68 @@
+package maze
+
+// deadEndFill fills in every dead end of the maze, other than the start and the goal, until there are none left.
+// Filling a dead end can turn the node next to it into a dead end, so each fill keeps going down the corridor until it reaches a junction.
+// It returns:
+// - a boolean which is true if the start and the goal are still connected
+// - a path slice for each dead end it started filling from, in the order the nodes were filled, so they can be drawn at the same time
+// - a solution slice with the nodes that were not filled, starting with the goal and going outwards
+// In a perfect maze, only the solution is left, so it ends with the start like bfs.
+// Loops can't be filled, so in a maze with loops it also has every loop connected to the goal.
+// Passages are open from both sides, even if they only go one way.
+func deadEndFill(g *graph, startIndex int, goalIndex int) (exists bool, paths *[][]int, solution *[]int) {
+	// Nodes next to each other through an edge in either direction
+	adjacent := make([][]int, len(g.nodes))
+	for _, n := range g.nodes {
+		for _, adj := range n.neighbors {
+			if !g.hasEdge(adj.n.index, n.index) {
+				adjacent[adj.n.index] = append(adjacent[adj.n.index], n.index)
+			}
+			adjacent[n.index] = append(adjacent[n.index], adj.n.index)
+		}
+	}
+	filled := make([]bool, len(g.nodes))
+	open := make([]int, len(g.nodes))
+	for index := range g.nodes {
+		open[index] = len(adjacent[index])
+		// Nodes that are walled in on every side are already out of the way
+		if open[index] == 0 && index != startIndex && index != goalIndex {
+			filled[index] = true
+		}
+	}
+	isDeadEnd := func(index int) bool {
+		return !filled[index] && open[index] == 1 && index != startIndex && index != goalIndex
+	}
+
+	pathsOut := make([][]int, 0)
+	for index := range g.nodes {
+		pathOut := make([]int, 0)
+		for current := index; isDeadEnd(current); {
+			filled[current] = true
+			pathOut = append(pathOut, current)
+			for _, n := range adjacent[current] {
+				if !filled[n] {
+					open[n]--
+					current = n
+					break
+				}
+			}
+		}
+		if len(pathOut) > 0 {
+			pathsOut = append(pathsOut, pathOut)
+		}
+	}
+
+	// Gather what is left, going outwards from the goal
+	solutionOut := []int{goalIndex}
+	reached := make([]bool, len(g.nodes))
+	reached[goalIndex] = true
+	for i := 0; i < len(solutionOut); i++ {
+		for _, n := range adjacent[solutionOut[i]] {
+			if !filled[n] && !reached[n] {
+				reached[n] = true
+				solutionOut = append(solutionOut, n)
+			}
+		}
+	}
+	return reached[startIndex], &pathsOut, &solutionOut
+}
@@ -6,11 +6,15 @@ import "sync"
 func getSeekerLocations(m *maze, numSeekers int) []int {
 	// Math to place evenly spaced seekers in the middle of the maze
 	spacerForIndex := m.width / numSeekers
-	rowForIndex := m.height*m.width/2 - spacerForIndex/2
+	rowForIndex := len(m.g.nodes)/2 - spacerForIndex/2
 	starts := make([]int, numSeekers, numSeekers)
 
 	for i := 0; i < numSeekers; i++ {
 		starts[i] = rowForIndex + spacerForIndex*i
+		// Seekers can't start on a masked node, since it isn't connected to anything
+		for m.isMasked(starts[i]) {
+			starts[i] = (starts[i] + 1) % len(m.g.nodes)
+		}
 	}
 	return starts
 }
@@ -0,0 +1,58 @@
+package maze
+
+import "container/heap"
+
+// dijkstra finds the first node with a given value by searching the cheapest nodes to reach first, using the weight of every edge.
+// It returns the same values as bfs, along with the total weight of the edges in the solution.
+// Unlike bfs, the solution is the cheapest path, which can take more steps than the shortest one.
+func dijkstra(g *graph, val int, startIndex int) (exists bool, path *[][]int, solution *[]int, cost int) {
+	pathOut := make([]int, 0)
+	solutionOut := make([]int, 0)
+	visited := make([]bool, len(g.nodes), len(g.nodes))
+	parents := make([]int, len(g.nodes), len(g.nodes))
+	costs := make([]int, len(g.nodes), len(g.nodes))
+	for i := range costs {
+		costs[i] = -1
+	}
+
+	queue := &priorityQueue{{index: startIndex}}
+	parents[startIndex] = -1
+	costs[startIndex] = 0
+	valIndex := -1
+
+	for queue.Len() > 0 {
+		current := heap.Pop(queue).(queueItem)
+		// A node can be queued more than once if a cheaper way to it is found later
+		if visited[current.index] {
+			continue
+		}
+		visited[current.index] = true
+		if g.nodes[current.index].val == val {
+			valIndex = current.index
+			break
+		}
+		pathOut = append(pathOut, current.index)
+
+		for _, adj := range g.nodes[current.index].neighbors {
+			next := adj.n.index
+			nextCost := current.cost + adj.weight
+			if !visited[next] && (costs[next] == -1 || nextCost < costs[next]) {
+				costs[next] = nextCost
+				parents[next] = current.index
+				heap.Push(queue, queueItem{index: next, cost: nextCost, priority: float64(nextCost)})
+			}
+		}
+	}
+
+	// Backtrack through parents to find the cheapest path.
+	if valIndex != -1 {
+		// Start at the goal node.
+		i := valIndex
+		for i != -1 {
+			solutionOut = append(solutionOut, i)
+			i = parents[i]
+		}
+		return true, &[][]int{pathOut}, &solutionOut, costs[valIndex]
+	}
+	return false, &[][]int{pathOut}, &solutionOut, 0
+}
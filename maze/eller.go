@@ -0,0 +1,115 @@
+package maze
+
+import "math/rand"
+
+// RowEmitter receives the rows of a maze from top to bottom as they are generated.
+// Returning an error stops generation, and the error is passed back to the caller.
+type RowEmitter func(row []MNode) error
+
+// StreamEllerMaze generates a maze using Eller's algorithm, passing each row to emit as soon as it is finished.
+// Only one row of state is kept at a time, so the height of the maze is not limited by memory.
+// Like makeMaze, the bottom right node is the goal.
+//
+// Eller's algorithm keeps track of which nodes in the current row are already connected (their set).
+// For each row, it randomly removes walls between neighbors in different sets, joining the sets.
+// Then it removes at least one wall below every set, so nothing is cut off from the rows below.
+// The last row removes every wall between different sets, which connects the whole maze.
+func StreamEllerMaze(width int, height int, emit RowEmitter) error {
+	if width < 1 || height < 1 {
+		return mkErr("invalid maze size")
+	}
+
+	// Labels identify the set of each node in the current row.
+	// Nodes carried down from the last row keep a label below width, and new nodes get a label of width or more.
+	labels := make([]int, width, width)
+	for col := range labels {
+		labels[col] = width + col
+	}
+	// openAbove is true if the node in the last row above each column has no wall below it.
+	openAbove := make([]bool, width, width)
+
+	for row := 0; row < height; row++ {
+		lastRow := row == height-1
+
+		// Rebuild the sets of this row from the labels
+		sets := makeDisjointSet(width)
+		firstWithLabel := make(map[int]int)
+		for col, label := range labels {
+			if first, ok := firstWithLabel[label]; ok {
+				sets.union(first, col)
+			} else {
+				firstWithLabel[label] = col
+			}
+		}
+
+		nodes := make([]MNode, width, width)
+		for col := range nodes {
+			nodes[col] = MNode{Val: NODE_EMPTY, Up: !openAbove[col], Down: true, Right: true, Left: true}
+		}
+
+		// Join neighbors in different sets
+		for col := 0; col < width-1; col++ {
+			if sets.find(col) != sets.find(col+1) && (lastRow || rand.Intn(2) == 0) {
+				sets.union(col, col+1)
+				nodes[col].Right = false
+				nodes[col+1].Left = false
+			}
+		}
+
+		// Open at least one node below every set
+		if !lastRow {
+			members := make(map[int][]int)
+			for col := 0; col < width; col++ {
+				root := sets.find(col)
+				members[root] = append(members[root], col)
+			}
+			for _, cols := range members {
+				forced := cols[rand.Intn(len(cols))]
+				for _, col := range cols {
+					if col == forced || rand.Intn(2) == 0 {
+						nodes[col].Down = false
+					}
+				}
+			}
+		}
+
+		// Carry the sets down to the next row
+		for col := 0; col < width; col++ {
+			openAbove[col] = !nodes[col].Down
+			if openAbove[col] {
+				labels[col] = sets.find(col)
+			} else {
+				labels[col] = width + col
+			}
+		}
+
+		if lastRow {
+			nodes[width-1].Val = NODE_GOAL
+		}
+		err := emit(nodes)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createEllerMaze generates a new maze using Eller's algorithm by removing the walls of each row emitted by StreamEllerMaze.
+func createEllerMaze(m *maze) {
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	row := 0
+	StreamEllerMaze(m.width, m.height, func(nodes []MNode) error {
+		for col, n := range nodes {
+			if !n.Down {
+				m.setWall(row, col, row+1, col, true)
+			}
+			if !n.Right {
+				m.setWall(row, col, row, col+1, true)
+			}
+		}
+		row++
+		return nil
+	})
+}
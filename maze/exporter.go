@@ -6,16 +6,83 @@ type MNode struct {
 	Val int
 	// If true, there is a wall in that direction.
 	// If false, there is no wall in that direction (which is an edge in the underlying graph).
+	// On the edge of a maze that wraps, no wall is a passage to the node on the other side.
 	Up    bool
 	Down  bool
 	Right bool
 	Left  bool
+	// If true, there are stairs to the level above or below.
+	// These are the opposite of walls, because most nodes don't have stairs.
+	StairsUp   bool
+	StairsDown bool
+	// If true, a tunnel goes under this node from up to down, or from right to left.
+	// The walls on the sides the tunnel goes through belong to the passage over it.
+	UnderVertical   bool
+	UnderHorizontal bool
+	// If true, the node is not part of the maze (see GenOptions.Mask)
+	Masked bool
+	// Portal is the number of the portal at this node, which is the same at both ends. It is 0 if there is no portal.
+	Portal int
+	// One-way passages are not walls, but can only be passed in one direction.
+	// For each side, this is ONEWAY_OUT if the passage only leads out of this node, ONEWAY_IN if it only leads in, and ONEWAY_NONE otherwise.
+	OneWayUp    int
+	OneWayDown  int
+	OneWayRight int
+	OneWayLeft  int
+	// Terrain is the TERRAIN_ of this node, which decides the cost of moving onto it
+	Terrain int
+}
+
+// MCell describes a node of any shape.
+// It is used to export mazes whose nodes can't be described by MNode.
+type MCell struct {
+	Val int
+	Row int
+	Col int
+	// Walls has an entry for each side of the node, in the order given by the maze's shape (see sides).
+	// If true, there is a wall on that side.
+	Walls []bool
+	// Neighbors has an entry for each side like Walls, which is the index of the node on the other side, or -1 if there is none or either node is masked
+	Neighbors []int
+	// If true, the node is not part of the maze (see GenOptions.Mask)
+	Masked bool
+	// Portal is the number of the portal at this node, which is the same at both ends. It is 0 if there is no portal.
+	Portal int
+	// OneWay has an entry for each side like Walls, which is one of the ONEWAY_ directions (see MNode)
+	OneWay []int
+	// Terrain is the TERRAIN_ of this node, which decides the cost of moving onto it
+	Terrain int
 }
 
 // Prefixes
 const (
 	GEN   = "GEN_"
 	SOLVE = "SOLVE_"
+	SHAPE = "SHAPE_"
+)
+
+// Biases point to the corner that passages in biased mazes lead towards
+const (
+	BIAS_NE = "BIAS_NE"
+	BIAS_NW = "BIAS_NW"
+	BIAS_SE = "BIAS_SE"
+	BIAS_SW = "BIAS_SW"
+)
+
+// Wraps pick which edges of the maze are next to each other
+const (
+	WRAP_NONE       = "WRAP_NONE"
+	WRAP_HORIZONTAL = "WRAP_HORIZONTAL"
+	WRAP_VERTICAL   = "WRAP_VERTICAL"
+	WRAP_BOTH       = "WRAP_BOTH"
+)
+
+// Heuristics guess how far a node is from the goal for SOLVE_ASTAR.
+// HEURISTIC_ZERO always guesses 0, which makes A* the same as Dijkstra's algorithm.
+const (
+	HEURISTIC_MANHATTAN = "HEURISTIC_MANHATTAN"
+	HEURISTIC_EUCLIDEAN = "HEURISTIC_EUCLIDEAN"
+	HEURISTIC_ZERO      = "HEURISTIC_ZERO"
 )
 
 // Suffixes
@@ -26,13 +93,35 @@ const (
 
 // Full selectors
 const (
-	GEN_DFS  = GEN + "DFS"
-	GEN_RAND = GEN + "RAND"
-	GEN_NONE = GEN + "NONE"
+	GEN_DFS           = GEN + "DFS"
+	GEN_RAND          = GEN + "RAND"
+	GEN_NONE          = GEN + "NONE"
+	GEN_PRIM          = GEN + "PRIM"
+	GEN_KRUSKAL       = GEN + "KRUSKAL"
+	GEN_WILSON        = GEN + "WILSON"
+	GEN_ALDOUS_BRODER = GEN + "ALDOUS_BRODER"
+	GEN_ELLER         = GEN + "ELLER"
+	GEN_DIVISION      = GEN + "DIVISION"
+	GEN_BINARY_TREE   = GEN + "BINARY_TREE"
+	GEN_SIDEWINDER    = GEN + "SIDEWINDER"
+	GEN_GROWING_TREE  = GEN + "GROWING_TREE"
+	GEN_HUNT_KILL     = GEN + "HUNT_KILL"
+
+	SOLVE_DFS_MULTI         = SOLVE + "DFS" + MULTI
+	SOLVE_BFS_SINGLE        = SOLVE + "BFS" + SINGLE
+	SOLVE_BFS_MULTI         = SOLVE + "BFS" + MULTI
+	SOLVE_ASTAR             = SOLVE + "ASTAR"
+	SOLVE_DIJKSTRA          = SOLVE + "DIJKSTRA"
+	SOLVE_BFS_BIDIRECTIONAL = SOLVE + "BFS_BIDIRECTIONAL"
 
-	SOLVE_DFS_MULTI  = SOLVE + "DFS" + MULTI
-	SOLVE_BFS_SINGLE = SOLVE + "BFS" + SINGLE
-	SOLVE_BFS_MULTI  = SOLVE + "BFS" + MULTI
+	SOLVE_WALL_FOLLOWER_LEFT  = SOLVE + "WALL_FOLLOWER_LEFT"
+	SOLVE_WALL_FOLLOWER_RIGHT = SOLVE + "WALL_FOLLOWER_RIGHT"
+	SOLVE_DEAD_END_FILL       = SOLVE + "DEAD_END_FILL"
+
+	SHAPE_SQUARE   = SHAPE + "SQUARE"
+	SHAPE_HEX      = SHAPE + "HEX"
+	SHAPE_POLAR    = SHAPE + "POLAR"
+	SHAPE_TRIANGLE = SHAPE + "TRIANGLE"
 )
 
 func mkErr(message string) error {
@@ -41,38 +130,102 @@ func mkErr(message string) error {
 
 func makeMNode(m *maze, row int, col int) MNode {
 	var newMNode MNode
-	newMNode.Val = m.g.nodes[getMazeIndex(m, row, col)].val
+	index := getMazeIndex(m, row, col)
+	newMNode.Val = m.g.nodes[index].val
+	newMNode.Masked = m.isMasked(index)
+	newMNode.Portal = m.portalLabel(index)
+	newMNode.Terrain = m.terrainAt(index)
 	// An edge is the absence of a wall
-	// If it has no edge, it has a wall
-	if row == 0 || !m.g.hasEdge(getMazeIndex(m, row, col), getMazeIndex(m, row-1, col)) {
-		newMNode.Up = true
+	// If it has no edge (or there is no node on that side), it has a wall
+	sides := m.sides(index)
+	// A one-way passage is open from either side
+	open := func(side int) bool {
+		n := sides[side]
+		return n != -1 && (m.g.hasEdge(index, n) || m.g.hasEdge(n, index) || m.hasTunnel(index, side))
 	}
-	if row == m.height-1 || !m.g.hasEdge(getMazeIndex(m, row, col), getMazeIndex(m, row+1, col)) {
-		newMNode.Down = true
+	oneWay := func(side int) int {
+		if sides[side] == -1 {
+			return ONEWAY_NONE
+		}
+		return m.oneWay(index, sides[side])
 	}
-	if col == m.width-1 || !m.g.hasEdge(getMazeIndex(m, row, col), getMazeIndex(m, row, col+1)) {
-		newMNode.Right = true
+	newMNode.Up = !open(SQUARE_UP)
+	newMNode.Down = !open(SQUARE_DOWN)
+	newMNode.Right = !open(SQUARE_RIGHT)
+	newMNode.Left = !open(SQUARE_LEFT)
+	newMNode.OneWayUp = oneWay(SQUARE_UP)
+	newMNode.OneWayDown = oneWay(SQUARE_DOWN)
+	newMNode.OneWayRight = oneWay(SQUARE_RIGHT)
+	newMNode.OneWayLeft = oneWay(SQUARE_LEFT)
+	if vertical, crossed := m.crossings[index]; crossed {
+		newMNode.UnderVertical = vertical
+		newMNode.UnderHorizontal = !vertical
 	}
-	if col == 0 || !m.g.hasEdge(getMazeIndex(m, row, col), getMazeIndex(m, row, col-1)) {
-		newMNode.Left = true
+	if m.levels > 1 {
+		newMNode.StairsUp = open(SQUARE_ABOVE)
+		newMNode.StairsDown = open(SQUARE_BELOW)
 	}
 
 	return newMNode
 }
 
+func makeMCell(m *maze, index int) MCell {
+	var newMCell MCell
+	newMCell.Val = m.g.nodes[index].val
+	newMCell.Row, newMCell.Col = getMazeCoords(m, index)
+	newMCell.Masked = m.isMasked(index)
+	newMCell.Portal = m.portalLabel(index)
+	newMCell.Terrain = m.terrainAt(index)
+	sides := m.sides(index)
+	newMCell.Neighbors = sides
+	newMCell.Walls = make([]bool, len(sides))
+	newMCell.OneWay = make([]int, len(sides))
+	for i, n := range sides {
+		// An edge is the absence of a wall, even if it only goes one way
+		newMCell.Walls[i] = n == -1 || (!m.g.hasEdge(index, n) && !m.g.hasEdge(n, index))
+		if n != -1 {
+			newMCell.OneWay[i] = m.oneWay(index, n)
+		}
+	}
+	return newMCell
+}
+
+// mazeToCells outputs a slice with an MCell for every node, in order of index.
+func mazeToCells(m *maze) []MCell {
+	cells := make([]MCell, len(m.g.nodes))
+	for index := range m.g.nodes {
+		cells[index] = makeMCell(m, index)
+	}
+	return cells
+}
+
 // mazeToSlice outputs a 2d slice that contains the values of every node as well as boolean values representing the existence of a wall up, down, right, and left.
 func mazeToSlice(m *maze) *[][]MNode {
+	return levelToSlice(m, 0)
+}
+
+// levelToSlice is mazeToSlice for one level of the maze
+func levelToSlice(m *maze, level int) *[][]MNode {
 	nodes := make([][]MNode, m.height)
 	for row := 0; row < m.height; row++ {
 		newRow := make([]MNode, m.width)
 		for col := 0; col < m.width; col++ {
-			newRow[col] = makeMNode(m, row, col)
+			newRow[col] = makeMNode(m, level*m.height+row, col)
 		}
 		nodes[row] = newRow
 	}
 	return &nodes
 }
 
+// mazeToLevels outputs a slice from levelToSlice for every level, starting with the top level.
+func mazeToLevels(m *maze) []*[][]MNode {
+	levels := make([]*[][]MNode, m.levels)
+	for level := range levels {
+		levels[level] = levelToSlice(m, level)
+	}
+	return levels
+}
+
 /*
 func reverseMNode(m *maze, n MNode, row int, col int) {
 	m.setSquare(row, col, n.Val)
@@ -103,26 +256,182 @@ func sliceToMaze(nodes *[][]MNode) *maze {
 }
 */
 
-func makeMaze(width int, height int, density int, generateAlg string) (*maze, error) {
+// GenOptions holds settings for the generation algorithms.
+// Each algorithm only reads the settings it uses.
+type GenOptions struct {
+	// Density is used by GEN_RAND. Increased density increases the number of walls.
+	Density int
+	// Bias is used by GEN_BINARY_TREE and GEN_SIDEWINDER to pick the corner passages lead towards.
+	Bias string
+	// Policy is used by GEN_GROWING_TREE to pick the next node to grow from.
+	// See parseGrowPolicy for the format.
+	Policy string
+	// Braid is the percent of dead ends to remove after any algorithm, which adds loops.
+	Braid int
+	// Solvable removes the fewest walls needed to connect the start to the goal after any algorithm.
+	// This is needed for GEN_RAND, which often cuts off the goal.
+	Solvable bool
+	// Shape is the SHAPE_ of every node. If it is empty, the nodes are squares.
+	Shape string
+	// Levels is the number of levels of square nodes, connected by stairs. 0 is the same as 1, which is a flat maze.
+	Levels int
+	// Weave is used by GEN_DFS. It is the percent chance of tunneling under a passage when DFS is blocked by it.
+	Weave int
+	// Wrap is one of the WRAP_ selectors, which makes the edges of a maze of square nodes wrap around. If it is empty, no edges wrap.
+	Wrap string
+	// Mask leaves out every node where it is true, which gives the maze its shape (see ParseMask).
+	// If it is set, the maze is the size of the mask instead of the width and height it was given.
+	Mask [][]bool
+	// PortalPairs lists portals to add, as the indexes of both ends (see ParsePortalPairs).
+	// Portals adds that many more portals between random nodes.
+	PortalPairs [][]int
+	Portals     int
+	// OneWay is the percent of passages that are made one-way, after the start is connected to the goal
+	OneWay int
+	// Terrain is the percent of nodes covered by regions of road, mud and water, which change the cost of moving onto them.
+	// If it is 0, every move costs the same.
+	Terrain int
+	// Heuristic is used by SOLVE_ASTAR. It is one of the HEURISTIC_ selectors, and if it is empty, it is HEURISTIC_MANHATTAN.
+	Heuristic string
+}
+
+// SquareOnly returns true if the generation algorithm only works on rows and columns of square nodes
+func SquareOnly(generateAlg string) bool {
+	switch generateAlg {
+	case GEN_ELLER, GEN_DIVISION, GEN_BINARY_TREE, GEN_SIDEWINDER:
+		return true
+	}
+	return false
+}
+
+// CheckGrowPolicy returns an error if policy is not a valid policy for GEN_GROWING_TREE
+func CheckGrowPolicy(policy string) error {
+	_, err := parseGrowPolicy(policy)
+	return err
+}
+
+// NodeCount returns the number of nodes in a maze of the given size and shape, which is how many start indexes it has.
+// Polar mazes only use the height, and only square nodes can have more than one level.
+func NodeCount(width int, height int, shape string, levels int) int {
+	if width < 1 || height < 1 {
+		return 0
+	}
+	switch shape {
+	case SHAPE_POLAR:
+		return polarRingStarts(height)[height]
+	case SHAPE_SQUARE, "":
+		if levels > 1 {
+			return width * height * levels
+		}
+	}
+	return width * height
+}
+
+func makeMaze(width int, height int, generateAlg string, opts GenOptions) (*maze, error) {
 	// Init maze with a given algorithm
-	maze := initMaze(height, width)
-	maze.setSquare(height-1, width-1, NODE_GOAL)
+	var maze *maze
+	levels := opts.Levels
+	if levels == 0 {
+		levels = 1
+	}
+	if opts.Mask != nil {
+		if levels > 1 || opts.Shape == SHAPE_POLAR {
+			return nil, mkErr("masks only work with one level of rows and columns")
+		}
+		height, width = len(opts.Mask), len(opts.Mask[0])
+	}
+	if levels > 1 && opts.Shape != SHAPE_SQUARE && opts.Shape != "" {
+		return nil, mkErr("only square nodes can have levels")
+	}
+	switch opts.Shape {
+	case SHAPE_SQUARE, "":
+		maze = initLevelMaze(levels, height, width)
+	case SHAPE_HEX:
+		maze = initHexMaze(height, width)
+	case SHAPE_POLAR:
+		maze = initPolarMaze(height)
+	case SHAPE_TRIANGLE:
+		maze = initTriangleMaze(height, width)
+	default:
+		return nil, mkErr("invalid shape")
+	}
+	if maze == nil {
+		return nil, mkErr("invalid maze size")
+	}
+	if err := maze.setWrap(opts.Wrap); err != nil {
+		return nil, err
+	}
+	if opts.Mask != nil {
+		if err := maze.setMask(opts.Mask); err != nil {
+			return nil, err
+		}
+	}
+	if opts.Terrain > 0 {
+		maze.paintTerrain(opts.Terrain)
+	}
+	for _, pair := range opts.PortalPairs {
+		if len(pair) != 2 {
+			return nil, mkErr("portals must have 2 ends")
+		}
+		if err := maze.addPortal(pair[0], pair[1]); err != nil {
+			return nil, err
+		}
+	}
+	if err := maze.addRandomPortals(opts.Portals); err != nil {
+		return nil, err
+	}
+	if (maze.shape != SHAPE_SQUARE || maze.levels > 1 || maze.wrapRows || maze.wrapCols || maze.masked != nil) && SquareOnly(generateAlg) {
+		return nil, mkErr("generation algorithm only works with one level of square nodes that don't wrap or have a mask")
+	}
+	// The goal is the last node, which is the bottom right corner of the bottom level (or the end of the outer ring).
+	// If that node is masked, it is the last node that isn't.
+	maze.goal = maze.lastNode()
+	maze.g.setNode(maze.goal, NODE_GOAL)
+	var err error
 	switch generateAlg {
 	case GEN_RAND:
-		randomizeMaze(maze, density)
+		randomizeMaze(maze, opts.Density)
 	case GEN_DFS:
-		createDFSMaze(maze)
+		createDFSMaze(maze, opts.Weave)
+	case GEN_PRIM:
+		createPrimMaze(maze)
+	case GEN_KRUSKAL:
+		createKruskalMaze(maze)
+	case GEN_WILSON:
+		createWilsonMaze(maze)
+	case GEN_ALDOUS_BRODER:
+		createAldousBroderMaze(maze)
+	case GEN_ELLER:
+		createEllerMaze(maze)
+	case GEN_DIVISION:
+		createDivisionMaze(maze)
+	case GEN_BINARY_TREE:
+		err = createBinaryTreeMaze(maze, opts.Bias)
+	case GEN_SIDEWINDER:
+		err = createSidewinderMaze(maze, opts.Bias)
+	case GEN_HUNT_KILL:
+		createHuntAndKillMaze(maze)
+	case GEN_GROWING_TREE:
+		err = createGrowingTreeMaze(maze, opts.Policy)
 	case GEN_NONE:
 		maze.setAllWalls(false)
 	default:
 		return nil, mkErr("invalid generation algorithm")
 	}
+	if err != nil {
+		return nil, err
+	}
+	if opts.Braid > 0 {
+		braidMaze(maze, opts.Braid)
+	}
 	return maze, nil
 }
 
-func solveMaze(m *maze, solveAlg string, startIndex int) (*[][]int, *[]int, error) {
+// solveMaze returns every path the solving algorithm searched, the solution from the goal back to the start, and the cost of walking the solution.
+// The cost is 0 for SOLVE_DEAD_END_FILL, whose solution isn't a path once the maze has loops.
+func solveMaze(m *maze, solveAlg string, startIndex int, heuristic string) (*[][]int, *[]int, int, error) {
 	if m == nil {
-		return nil, nil, mkErr("invalid maze")
+		return nil, nil, 0, mkErr("invalid maze")
 	}
 	var ok bool
 	var searchPaths *[][]int
@@ -131,31 +440,64 @@ func solveMaze(m *maze, solveAlg string, startIndex int) (*[][]int, *[]int, erro
 	case SOLVE_DFS_MULTI:
 		ok, best = dfs(&m.g, 3, startIndex)
 		if !ok {
-			return nil, nil, mkErr("DFS singlethreaded failed")
+			return nil, nil, 0, mkErr("DFS singlethreaded failed")
 		}
 		ok, searchPaths = dfsMultithreaded(&m.g, 3, getSeekerLocations(m, 4))
 		if !ok {
-			return nil, nil, mkErr("DFS multithreaded failed")
+			return nil, nil, 0, mkErr("DFS multithreaded failed")
 		}
 	case SOLVE_BFS_MULTI:
 		ok, searchPaths, best = bfsMultithreaded(&m.g, 3, startIndex, 4)
 		if !ok {
-			return nil, nil, mkErr("BFS multithreaded failed")
+			return nil, nil, 0, mkErr("BFS multithreaded failed")
 		}
 	case SOLVE_BFS_SINGLE:
 		ok, searchPaths, best = bfs(&m.g, 3, startIndex)
 		if !ok {
-			return nil, nil, mkErr("BFS singlethreaded failed")
+			return nil, nil, 0, mkErr("BFS singlethreaded failed")
+		}
+	case SOLVE_BFS_BIDIRECTIONAL:
+		ok, searchPaths, best = bfsBidirectional(&m.g, startIndex, m.goal)
+		if !ok {
+			return nil, nil, 0, mkErr("BFS bidirectional failed")
+		}
+	case SOLVE_ASTAR:
+		var err error
+		ok, searchPaths, best, err = astar(m, startIndex, heuristic)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		if !ok {
+			return nil, nil, 0, mkErr("A* failed")
+		}
+	case SOLVE_WALL_FOLLOWER_LEFT, SOLVE_WALL_FOLLOWER_RIGHT:
+		var err error
+		_, searchPaths, best, err = wallFollower(m, startIndex, solveAlg == SOLVE_WALL_FOLLOWER_LEFT)
+		if err != nil {
+			return nil, nil, 0, err
 		}
+	case SOLVE_DEAD_END_FILL:
+		ok, searchPaths, best = deadEndFill(&m.g, startIndex, m.goal)
+		if !ok {
+			return nil, nil, 0, mkErr("dead end filling failed")
+		}
+		return searchPaths, best, 0, nil
+	case SOLVE_DIJKSTRA:
+		var cost int
+		ok, searchPaths, best, cost = dijkstra(&m.g, 3, startIndex)
+		if !ok {
+			return nil, nil, 0, mkErr("Dijkstra failed")
+		}
+		return searchPaths, best, cost, nil
 	default:
-		return nil, nil, mkErr("invalid solving algorithm")
+		return nil, nil, 0, mkErr("invalid solving algorithm")
 	}
-	return searchPaths, best, nil
+	return searchPaths, best, pathCost(&m.g, *best), nil
 }
 
 /*
-func MakeMaze(width int, height int, density int, generateAlg string) (*[][]MNode, error) {
-	m, err := makeMaze(width, height, density, generateAlg)
+func MakeMaze(width int, height int, generateAlg string, opts GenOptions) (*[][]MNode, error) {
+	m, err := makeMaze(width, height, generateAlg, opts)
 	return mazeToSlice(m), err
 }
 
@@ -167,15 +509,72 @@ func SolveMaze(nodes *[][]MNode, solveAlg string, startIndex int) (*[][]int, *[]
 }
 */
 
-// MakeSolveMaze returns (maze as slice, all paths, best path, error)
-func MakeSolveMaze(width int, height int, density int, generateAlg string, solveAlg string, startIndex int) (*[][]MNode, *[][]int, *[]int, error) {
-	m, err := makeMaze(width, height, density, generateAlg)
+// Result holds everything needed to display a generated and solved maze.
+type Result struct {
+	// Shape is the SHAPE_ of every node, which decides whether the maze is in Nodes or Cells
+	Shape string
+	// Nodes is the maze as a slice, from mazeToSlice, if the nodes are squares on one level
+	Nodes *[][]MNode
+	// Levels is the maze as a slice for each level, from mazeToLevels, if the nodes are squares on more than one level.
+	// Rows in Paths and Best continue from one level to the next, so row height is the first row of the second level.
+	Levels []*[][]MNode
+	// Cells is the maze as a slice, from mazeToCells, if the nodes are any other shape
+	Cells []MCell
+	// Paths contains every path the solving algorithm searched
+	Paths *[][]int
+	// Best is the solution, starting with the goal and ending with the start.
+	// SOLVE_DEAD_END_FILL can't fill loops, so in a maze with loops it has every node left over instead (see deadEndFill).
+	Best *[]int
+	// Cost is the total weight of the edges along Best, which is its length unless there is terrain.
+	// It is 0 for SOLVE_DEAD_END_FILL, since the nodes left over aren't a path that can be walked.
+	Cost int
+	// Divisions lists the walls added by GEN_DIVISION in the order they were added.
+	// See createDivisionMaze for the layout of each wall.
+	Divisions [][]int
+	// Carved is the number of walls removed to connect the start to the goal when GenOptions.Solvable is set
+	Carved int
+	// Portals lists both ends of every portal. The number of each portal in MNode and MCell is its place in the list, starting at 1.
+	Portals [][]int
+}
+
+// MakeSolveMaze generates and solves a maze
+func MakeSolveMaze(width int, height int, generateAlg string, opts GenOptions, solveAlg string, startIndex int) (*Result, error) {
+	m, err := makeMaze(width, height, generateAlg, opts)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
+	}
+	if startIndex < 0 || startIndex >= len(m.g.nodes) {
+		return nil, mkErr("invalid start index")
+	}
+	if m.isMasked(startIndex) {
+		return nil, mkErr("start index is masked")
 	}
-	p, b, err := solveMaze(m, solveAlg, startIndex)
+	carved := 0
+	if opts.Solvable {
+		carved = connectNodes(m, startIndex, m.goal)
+	}
+	if opts.OneWay > 0 {
+		makeOneWay(m, opts.OneWay, startIndex)
+	}
+	p, b, cost, err := solveMaze(m, solveAlg, startIndex, opts.Heuristic)
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, err
+	}
+	res := Result{
+		Shape:     m.shape,
+		Paths:     p,
+		Best:      b,
+		Cost:      cost,
+		Divisions: m.divisions,
+		Carved:    carved,
+		Portals:   m.portals,
+	}
+	if m.shape == SHAPE_SQUARE && m.levels > 1 {
+		res.Levels = mazeToLevels(m)
+	} else if m.shape == SHAPE_SQUARE {
+		res.Nodes = mazeToSlice(m)
+	} else {
+		res.Cells = mazeToCells(m)
 	}
-	return mazeToSlice(m), p, b, nil
+	return &res, nil
 }
@@ -1,13 +1,26 @@
 package maze
 
+// Kinds of edges
+const (
+	// EDGE_PASSAGE connects nodes that are next to each other
+	EDGE_PASSAGE = iota
+	// EDGE_TUNNEL goes under the node between two nodes in a straight line
+	EDGE_TUNNEL
+	// EDGE_PORTAL connects two nodes anywhere in the maze
+	EDGE_PORTAL
+)
+
 type neighbor struct {
 	n      *node
 	weight int
+	kind   int
 }
 
 type node struct {
-	val       int
-	index     int
+	val   int
+	index int
+	// cost is the weight of every edge into this node, which is the cost of moving onto it
+	cost      int
 	neighbors []*neighbor
 }
 
@@ -19,6 +32,7 @@ func makeNode(v int, i int) *node {
 	var newNode node
 	newNode.val = v
 	newNode.index = i
+	newNode.cost = 1
 	return &newNode
 }
 
@@ -33,22 +47,27 @@ func (g *graph) setNode(index int, val int) {
 	g.nodes[index].val = val
 }
 
-// addEdgeU is undirected and assumes all weights are 1
-func addEdgeU(n1 *node, n2 *node) {
-	n1.neighbors = append(n1.neighbors, &neighbor{n: n2, weight: 1})
-	n2.neighbors = append(n2.neighbors, &neighbor{n: n1, weight: 1})
+// addEdgeU is undirected, and the weight in each direction is the cost of the node it leads to
+func addEdgeU(n1 *node, n2 *node, kind int) {
+	n1.neighbors = append(n1.neighbors, &neighbor{n: n2, weight: n2.cost, kind: kind})
+	n2.neighbors = append(n2.neighbors, &neighbor{n: n1, weight: n1.cost, kind: kind})
 }
 
 // addEdge assumes edges are not directed, adding edges to
 // the other node for both i1 and i2
 func (g *graph) addEdge(i1 int, i2 int) {
+	g.addEdgeKind(i1, i2, EDGE_PASSAGE)
+}
+
+// addEdgeKind is addEdge for an edge of any kind
+func (g *graph) addEdgeKind(i1 int, i2 int, kind int) {
 	// Avoid duplicate edges
 	for _, adj := range g.nodes[i1].neighbors {
 		if adj.n.index == i2 {
 			return
 		}
 	}
-	addEdgeU(g.nodes[i1], g.nodes[i2])
+	addEdgeU(g.nodes[i1], g.nodes[i2], kind)
 }
 
 // removeEdgeU is unidirectional
@@ -67,14 +86,25 @@ func (g *graph) removeEdge(i1 int, i2 int) {
 	removeEdgeU(g, i2, i1)
 }
 
-// hasEdge assumes bidirectional
+// makeOneWay removes the half of the edge between i1 and i2 that goes from i2 back to i1
+func (g *graph) makeOneWay(i1 int, i2 int) {
+	removeEdgeU(g, i2, i1)
+}
+
+// hasEdge returns true if there is an edge from i1 to i2.
+// Edges go both ways, unless they have been made one-way.
 func (g *graph) hasEdge(i1 int, i2 int) bool {
+	return g.edgeKind(i1, i2) != -1
+}
+
+// edgeKind returns the kind of the edge from i1 to i2, or -1 if there is no edge from i1 to i2
+func (g *graph) edgeKind(i1 int, i2 int) int {
 	for _, adj := range g.nodes[i1].neighbors {
 		if adj.n.index == i2 {
-			return true
+			return adj.kind
 		}
 	}
-	return false
+	return -1
 }
 
 // LEGACY PRINT FUNCTIONS
@@ -0,0 +1,35 @@
+package maze
+
+// queueItem is a node waiting to be searched by a solver that uses a priorityQueue
+type queueItem struct {
+	index int
+	// cost is the total weight of the edges from the start to this node
+	cost int
+	// priority decides which node is searched next, lowest first
+	priority float64
+}
+
+// priorityQueue is a binary heap of nodes, ordered by lowest priority first.
+// Use it with container/heap.
+type priorityQueue []queueItem
+
+func (q priorityQueue) Len() int { return len(q) }
+
+// Less breaks ties with the node that is further from the start, so the search keeps going in the same direction
+func (q priorityQueue) Less(i, j int) bool {
+	if q[i].priority == q[j].priority {
+		return q[i].cost > q[j].cost
+	}
+	return q[i].priority < q[j].priority
+}
+
+func (q priorityQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *priorityQueue) Push(x any) { *q = append(*q, x.(queueItem)) }
+
+func (q *priorityQueue) Pop() any {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
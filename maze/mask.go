@@ -0,0 +1,155 @@
+package maze
+
+import (
+	"image/color"
+	"image/png"
+	"io"
+	"math/rand"
+	"strings"
+)
+
+// Masks give mazes any shape by leaving some nodes out of the maze.
+// A mask has a row for every row of the maze and a column for every column, and true means the node is masked.
+// Masked nodes stay in the graph so indexes don't change, but they have no neighbors and are never part of the maze.
+
+// ParseMask reads a mask from ASCII art, where each line is a row.
+// 'X' and '#' are masked, and any other character (such as '.' or ' ') is part of the maze.
+// Lines shorter than the longest line are filled in with nodes that are part of the maze.
+func ParseMask(text string) ([][]bool, error) {
+	lines := strings.Split(strings.ReplaceAll(text, "\r", ""), "\n")
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	width := 0
+	for _, line := range lines {
+		if len(line) > width {
+			width = len(line)
+		}
+	}
+	if width == 0 {
+		return nil, mkErr("empty mask")
+	}
+
+	mask := make([][]bool, len(lines))
+	for row, line := range lines {
+		mask[row] = make([]bool, width)
+		for col, c := range []byte(line) {
+			mask[row][col] = c == 'X' || c == '#'
+		}
+	}
+	return mask, nil
+}
+
+// ParsePNGMask reads a mask from a black and white PNG image, where each pixel is a node.
+// Dark pixels are masked, and light pixels are part of the maze.
+func ParsePNGMask(r io.Reader) ([][]bool, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return nil, mkErr("invalid mask image: " + err.Error())
+	}
+	bounds := img.Bounds()
+	mask := make([][]bool, bounds.Dy())
+	for row := range mask {
+		mask[row] = make([]bool, bounds.Dx())
+		for col := range mask[row] {
+			gray := color.GrayModel.Convert(img.At(bounds.Min.X+col, bounds.Min.Y+row)).(color.Gray)
+			mask[row][col] = gray.Y < 128
+		}
+	}
+	return mask, nil
+}
+
+// setMask masks the nodes of the maze where mask is true.
+// The nodes that are left must all be connected, or generators could never reach all of them.
+func (m *maze) setMask(mask [][]bool) error {
+	if len(mask) != m.height || len(mask[0]) != m.width {
+		return mkErr("mask must be the same size as the maze")
+	}
+	m.masked = make([]bool, len(m.g.nodes))
+	for row := range mask {
+		if len(mask[row]) != m.width {
+			return mkErr("every row of the mask must be the same length")
+		}
+		for col, masked := range mask[row] {
+			m.masked[getMazeIndex(m, row, col)] = masked
+		}
+	}
+
+	open := m.openNodes()
+	if open < 2 {
+		return mkErr("mask must leave at least 2 nodes")
+	}
+	// Flood fill from the first node to make sure everything is connected
+	visited := m.makeVisited()
+	queue := []int{m.firstNode()}
+	visited[queue[0]] = true
+	reached := 0
+	for len(queue) > 0 {
+		index := queue[0]
+		queue = queue[1:]
+		reached++
+		for _, n := range m.neighbors(index) {
+			if !visited[n] {
+				visited[n] = true
+				queue = append(queue, n)
+			}
+		}
+	}
+	if reached != open {
+		return mkErr("nodes left by the mask must all be connected")
+	}
+	return nil
+}
+
+// isMasked returns true if the node at index is not part of the maze
+func (m *maze) isMasked(index int) bool {
+	return m.masked != nil && m.masked[index]
+}
+
+// makeVisited returns a list of visited nodes for a generator to fill in.
+// Masked nodes start out visited, so they are never added to the maze.
+func (m *maze) makeVisited() []bool {
+	visited := make([]bool, len(m.g.nodes), len(m.g.nodes))
+	if m.masked != nil {
+		copy(visited, m.masked)
+	}
+	return visited
+}
+
+// openNodes returns the number of nodes that are not masked
+func (m *maze) openNodes() int {
+	open := len(m.g.nodes)
+	for index := range m.masked {
+		if m.masked[index] {
+			open--
+		}
+	}
+	return open
+}
+
+// firstNode returns the index of the first node that is not masked
+func (m *maze) firstNode() int {
+	index := 0
+	for m.isMasked(index) {
+		index++
+	}
+	return index
+}
+
+// lastNode returns the index of the last node that is not masked
+func (m *maze) lastNode() int {
+	index := len(m.g.nodes) - 1
+	for m.isMasked(index) {
+		index--
+	}
+	return index
+}
+
+// randomNode returns the index of a random node that is not masked
+func (m *maze) randomNode() int {
+	index := rand.Intn(len(m.g.nodes))
+	for m.isMasked(index) {
+		index = rand.Intn(len(m.g.nodes))
+	}
+	return index
+}
@@ -2,6 +2,8 @@ package maze
 
 import (
 	"math/rand"
+	"strconv"
+	"strings"
 )
 
 const (
@@ -12,9 +14,32 @@ const (
 )
 
 type maze struct {
-	g      graph
+	g graph
+	// shape is the SHAPE_ of every node, which decides which nodes are next to each other
+	shape string
+	// height is the number of rows in each level
 	height int
 	width  int
+	// levels is the number of levels stacked on top of each other, which is 1 for a flat maze.
+	// The rows of every level are stored one after another, so level 1 starts at row height.
+	levels int
+	// wrapRows and wrapCols are true if the left and right edges, or the top and bottom edges, are next to each other
+	wrapRows bool
+	wrapCols bool
+	// crossings has every node that a tunnel goes under, which is true if the tunnel goes up and down (see weave.go)
+	crossings map[int]bool
+	// masked is true for every node that is left out of the maze, or nil if there is no mask (see mask.go)
+	masked []bool
+	// portals lists both ends of every portal, in the order they were added (see portal.go)
+	portals [][]int
+	// terrain is the TERRAIN_ of every node, or nil if every node is plain (see terrain.go)
+	terrain []int
+	// goal is the index of the node solving algorithms search for
+	goal int
+	// divisions records the walls added by createDivisionMaze, in order
+	divisions [][]int
+	// ringStarts is the index of the first node of every ring in a polar maze (see initPolarMaze)
+	ringStarts []int
 }
 
 func initMaze(height int, width int) *maze {
@@ -30,8 +55,10 @@ func initMaze(height int, width int) *maze {
 		g.addNode(NODE_EMPTY)
 	}
 
+	m.shape = SHAPE_SQUARE
 	m.height = height
 	m.width = width
+	m.levels = 1
 	return &m
 }
 
@@ -39,32 +66,28 @@ func initMaze(height int, width int) *maze {
 // If exists is true, all walls are added (no edges)
 // If exists is false, all walls are removed (edges between every node and its neighbors)
 func (m *maze) setAllWalls(exists bool) {
-	// Height is the number of rows, width is the number of columns
-	// Initialize with no walls, so every node is connected to the node to its Top, Bottom, Right, and Left
-	// Nodes fill left to right, then top to bottom
-	// Edges are added on  the bottom and right side, so the bottom row and right column are ignored, which is seen in the height-1 and width-1
-	for row := 0; row < m.height-1; row++ {
-		for col := 0; col < m.width-1; col++ {
-			//edge to below
-			m.setWall(row, col, row+1, col, !exists)
-			//edge to the right
-			m.setWall(row, col, row, col+1, !exists)
+	// Each wall is set from the node with the lower index so it is only set once
+	for index := range m.g.nodes {
+		for _, n := range m.neighbors(index) {
+			if n > index {
+				m.setWallIndex(index, n, !exists)
+			}
 		}
-		// Add just below for the right column
-		m.setWall(row, m.width-1, row+1, m.width-1, !exists)
-	}
-	// Add just to the right for the bottom row, and nothing for the bottom right node, which is seen in the width-1
-	for col := 0; col < m.width-1; col++ {
-		m.setWall(m.height-1, col, m.height-1, col+1, !exists)
 	}
 }
 
 func getMazeIndex(m *maze, row int, col int) int {
+	if m.shape == SHAPE_POLAR {
+		return m.ringStarts[row] + col
+	}
 	return row*(m.width) + col
 }
 
 // getMazeCoords returns (row, col) from index
 func getMazeCoords(m *maze, index int) (int, int) {
+	if m.shape == SHAPE_POLAR {
+		return m.polarCoords(index)
+	}
 	return GetSquareCoords(index, m.width)
 }
 
@@ -77,13 +100,13 @@ func (m *maze) setSquare(row int, col int, val int) {
 }
 
 func (m *maze) setWall(row1 int, col1 int, row2 int, col2 int, remove bool) {
-	// For direction: 1 = up, 2 = down, 3 = right, 4 = left
-	// For removing: true = remove, false = add
-
 	//TODO error checking to make sure the nodes are next to each other
-	index1 := getMazeIndex(m, row1, col1)
-	index2 := getMazeIndex(m, row2, col2)
+	m.setWallIndex(getMazeIndex(m, row1, col1), getMazeIndex(m, row2, col2), remove)
+}
 
+// setWallIndex is setWall for nodes given by index
+// For removing: true = remove, false = add
+func (m *maze) setWallIndex(index1 int, index2 int, remove bool) {
 	// Adding an edge removes a wall
 	// Removing an edge adds a wall
 	// This is because graph algorithms can only travel over edges, so they must be gaps in the wall
@@ -97,71 +120,693 @@ func (m *maze) setWall(row1 int, col1 int, row2 int, col2 int, remove bool) {
 // randomizeMaze randomizes every wall in the maze
 // Increased density increases the number of walls; density=20 will have half the walls filled.
 func randomizeMaze(m *maze, density int) {
-	for row := 0; row < m.height-1; row++ {
-		for col := 0; col < m.width-1; col++ {
-			// Randomize edge below
-			m.setWall(row, col, row+1, col, rand.Intn(density) < 10)
-			// Randomize edge to the right
-			m.setWall(row, col, row, col+1, rand.Intn(density) < 10)
+	for index := range m.g.nodes {
+		for _, n := range m.neighbors(index) {
+			if n > index {
+				m.setWallIndex(index, n, rand.Intn(density) < 10)
+			}
+		}
+	}
+}
+
+// createDFSMaze generates a new maze using backtracking DFS.
+// First, it fills the maze with walls.
+// Then it runs DFS with no end condition, stopping once every node has been visited once.
+// Every time DFS moves between two nodes, it removes the wall in its way.
+// If DFS is blocked by a visited node with a passage across its way, it tunnels under that node weave percent of the time.
+func createDFSMaze(m *maze, weave int) {
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	visited := m.makeVisited()
+	createDFSMazeRecursive(m, m.firstNode(), &visited, weave)
+}
+
+func createDFSMazeRecursive(m *maze, index int, visited *[]bool, weave int) {
+	(*visited)[index] = true
+
+	// Nothing has neighbors because everything is wiped
+	sides := m.sides(index)
+	for _, side := range rand.Perm(len(sides)) {
+		n := sides[side]
+		if n == -1 {
+			continue
+		}
+
+		if !(*visited)[n] {
+			m.setWallIndex(index, n, true)
+			createDFSMazeRecursive(m, n, visited, weave)
+		} else if end := m.tunnelEnd(index, side, *visited); end != -1 && rand.Intn(100) < weave {
+			m.addTunnel(index, side, end)
+			createDFSMazeRecursive(m, end, visited, weave)
 		}
-		// Randomize just below for the right column
-		m.setWall(row, m.width-1, row+1, m.width-1, rand.Intn(density) < 10)
 	}
-	// Randomize just to the right for the bottom row, and nothing for the bottom right node
-	for col := 0; col < m.width-1; col++ {
-		m.setWall(m.height-1, col, m.height-1, col+1, rand.Intn(density) < 10)
+}
+
+// createHuntAndKillMaze generates a new maze using the hunt-and-kill algorithm.
+// It makes mazes that look like createDFSMaze, but it uses a loop instead of recursion so its stack use is bounded.
+// First, it fills the maze with walls.
+// Then it randomly walks from a random node into unvisited neighbors, removing the walls it passes through (the kill phase).
+// When the walk reaches a node with no unvisited neighbors, it scans the nodes in order for an unvisited node
+// next to a visited one (the hunt phase). It removes the wall between them and starts walking again from there.
+// It stops once the hunt finds no unvisited nodes.
+func createHuntAndKillMaze(m *maze) {
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	visited := m.makeVisited()
+	index := m.randomNode()
+	visited[index] = true
+	// Every node before huntStart has been visited, so the hunt doesn't need to scan it again
+	huntStart := 0
+
+	for {
+		// Kill
+		unvisited := make([]int, 0, 6)
+		for _, n := range m.neighbors(index) {
+			if !visited[n] {
+				unvisited = append(unvisited, n)
+			}
+		}
+		if len(unvisited) > 0 {
+			n := unvisited[rand.Intn(len(unvisited))]
+			m.setWallIndex(index, n, true)
+			index = n
+			visited[index] = true
+			continue
+		}
+
+		// Hunt
+		for huntStart < len(visited) && visited[huntStart] {
+			huntStart++
+		}
+		index = -1
+		for i := huntStart; i < len(visited) && index == -1; i++ {
+			if visited[i] {
+				continue
+			}
+			connected := make([]int, 0, 6)
+			for _, n := range m.neighbors(i) {
+				if visited[n] {
+					connected = append(connected, n)
+				}
+			}
+			if len(connected) > 0 {
+				m.setWallIndex(i, connected[rand.Intn(len(connected))], true)
+				index = i
+				visited[index] = true
+			}
+		}
+		if index == -1 {
+			return
+		}
+	}
+}
+
+// createPrimMaze generates a new maze using randomized Prim's algorithm.
+// First, it fills the maze with walls.
+// Then it grows the maze out from a single node, adding a random node from the frontier each step.
+// The frontier is every node that is not in the maze yet but is next to a node that is.
+// Every time a node is added, the wall between it and a random neighbor already in the maze is removed.
+func createPrimMaze(m *maze) {
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	inMaze := m.makeVisited()
+	inFrontier := make([]bool, len(m.g.nodes), len(m.g.nodes))
+
+	frontier := make([]int, 0)
+	start := m.firstNode()
+	inMaze[start] = true
+	frontier = addPrimFrontier(m, start, frontier, &inMaze, &inFrontier)
+
+	for len(frontier) > 0 {
+		// Swap a random node to the end of the frontier and pop it
+		i := rand.Intn(len(frontier))
+		frontier[i], frontier[len(frontier)-1] = frontier[len(frontier)-1], frontier[i]
+		index := frontier[len(frontier)-1]
+		frontier = frontier[:len(frontier)-1]
+
+		// Connect to a random neighbor that is already part of the maze
+		connected := make([]int, 0)
+		for _, n := range m.neighbors(index) {
+			if inMaze[n] {
+				connected = append(connected, n)
+			}
+		}
+		m.setWallIndex(index, connected[rand.Intn(len(connected))], true)
+
+		inMaze[index] = true
+		frontier = addPrimFrontier(m, index, frontier, &inMaze, &inFrontier)
 	}
 }
 
-func possibleNeighbors(m *maze, row int, col int) [][]int {
-	neighbors := make([][]int, 0)
-	if row > 0 {
-		neighbors = append(neighbors, []int{row - 1, col})
+// addPrimFrontier adds every neighbor of index that is not yet in the maze or the frontier to the frontier.
+func addPrimFrontier(m *maze, index int, frontier []int, inMaze *[]bool, inFrontier *[]bool) []int {
+	for _, n := range m.neighbors(index) {
+		if !(*inMaze)[n] && !(*inFrontier)[n] {
+			(*inFrontier)[n] = true
+			frontier = append(frontier, n)
+		}
 	}
-	if row < m.height-1 {
-		neighbors = append(neighbors, []int{row + 1, col})
+	return frontier
+}
+
+// createKruskalMaze generates a new maze using randomized Kruskal's algorithm.
+// First, it fills the maze with walls.
+// Then it shuffles every wall between two nodes and goes through them in that order.
+// A wall is removed if the nodes on either side are not connected yet, which is tracked with a disjoint set.
+// Unlike createDFSMaze, it does not recurse, so its stack use does not grow with the size of the maze.
+func createKruskalMaze(m *maze) {
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	// Each wall is stored as the indexes of the nodes on either side of it
+	// Only walls to nodes with a higher index are listed so each wall appears once
+	walls := make([][]int, 0, 2*len(m.g.nodes))
+	for index := range m.g.nodes {
+		for _, n := range m.neighbors(index) {
+			if n > index {
+				walls = append(walls, []int{index, n})
+			}
+		}
 	}
-	if col > 0 {
-		neighbors = append(neighbors, []int{row, col - 1})
+	rand.Shuffle(len(walls), func(i, j int) {
+		walls[i], walls[j] = walls[j], walls[i]
+	})
+
+	sets := makeDisjointSet(len(m.g.nodes))
+	for _, w := range walls {
+		if sets.union(w[0], w[1]) {
+			m.setWallIndex(w[0], w[1], true)
+		}
 	}
-	if col < m.width-1 {
-		neighbors = append(neighbors, []int{row, col + 1})
+}
+
+// createWilsonMaze generates a new maze using Wilson's algorithm.
+// Every possible maze (spanning tree of the grid) is equally likely to be generated.
+// First, it fills the maze with walls and adds a random node to the maze.
+// Then, from each node not yet in the maze, it randomly walks until it reaches the maze.
+// Whenever the walk crosses itself, the loop is erased by overwriting the direction it left each node in.
+// Once the walk reaches the maze, it is retraced from its start and every wall along it is removed.
+func createWilsonMaze(m *maze) {
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	totalNodes := len(m.g.nodes)
+	inMaze := m.makeVisited()
+	// next stores the index the random walk most recently moved to from each node
+	next := make([]int, totalNodes, totalNodes)
+	inMaze[m.randomNode()] = true
+
+	for _, start := range rand.Perm(totalNodes) {
+		if inMaze[start] {
+			continue
+		}
+
+		// Random walk until the maze is reached
+		current := start
+		for !inMaze[current] {
+			neighbors := m.neighbors(current)
+			next[current] = neighbors[rand.Intn(len(neighbors))]
+			current = next[current]
+		}
+
+		// Retrace the loop-erased walk, carving it into the maze
+		current = start
+		for !inMaze[current] {
+			inMaze[current] = true
+			m.setWallIndex(current, next[current], true)
+			current = next[current]
+		}
 	}
-	return neighbors
 }
 
-// createDFSMaze generates a new maze using backtracking DFS.
+// createAldousBroderMaze generates a new maze using the Aldous-Broder algorithm.
+// Like createWilsonMaze, every possible maze is equally likely to be generated.
 // First, it fills the maze with walls.
-// Then it runs DFS with no end condition, stopping once every node has been visited once.
-// Every time DFS moves between two nodes, it removes the wall in its way.
-func createDFSMaze(m *maze) {
+// Then it randomly walks from a random node until every node has been visited.
+// Every time the walk enters a node for the first time, it removes the wall it came through.
+// It is much slower than createWilsonMaze, but it is simple enough to check Wilson's results against.
+func createAldousBroderMaze(m *maze) {
 	// Wipe the maze, filling with all walls
 	m.setAllWalls(true)
 
-	visited := make([][]bool, m.height, m.height)
-	for i := range visited {
-		visited[i] = make([]bool, m.width, m.width)
+	visited := m.makeVisited()
+	index := m.randomNode()
+	visited[index] = true
+	remaining := m.openNodes() - 1
+
+	for remaining > 0 {
+		neighbors := m.neighbors(index)
+		n := neighbors[rand.Intn(len(neighbors))]
+		if !visited[n] {
+			visited[n] = true
+			m.setWallIndex(index, n, true)
+			remaining--
+		}
+		index = n
 	}
+}
+
+// biasDirections returns the vertical and horizontal directions a bias points towards as (row, col) offsets.
+// For example, BIAS_NE returns up (-1, 0) and right (0, 1).
+func biasDirections(bias string) (vertical []int, horizontal []int, err error) {
+	switch bias {
+	case BIAS_NE:
+		return []int{-1, 0}, []int{0, 1}, nil
+	case BIAS_NW:
+		return []int{-1, 0}, []int{0, -1}, nil
+	case BIAS_SE:
+		return []int{1, 0}, []int{0, 1}, nil
+	case BIAS_SW:
+		return []int{1, 0}, []int{0, -1}, nil
+	}
+	return nil, nil, mkErr("invalid bias")
+}
 
-	createDFSMazeRecursive(m, 0, 0, &visited)
+// inMaze returns true if (row, col) is inside the maze
+func (m *maze) inMaze(row int, col int) bool {
+	if m.shape == SHAPE_POLAR {
+		return row >= 0 && row < m.height && col >= 0 && col < m.ringSize(row)
+	}
+	return row >= 0 && row < m.height*m.levels && col >= 0 && col < m.width
 }
 
-func createDFSMazeRecursive(m *maze, row int, col int, visited *[][]bool) {
-	(*visited)[row][col] = true
+// createBinaryTreeMaze generates a new maze using the binary tree algorithm.
+// First, it fills the maze with walls.
+// Then every node removes the wall in one of the two directions of the bias, picked randomly.
+// Nodes on the edge of the maze only have one of the directions available, and the corner the bias points to has none.
+// This leaves two long corridors along the sides of the maze that the bias points to.
+func createBinaryTreeMaze(m *maze, bias string) error {
+	vertical, horizontal, err := biasDirections(bias)
+	if err != nil {
+		return err
+	}
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
 
-	// Nothing has neighbors because everything is wiped
-	neighbors := possibleNeighbors(m, row, col)
-	for len(neighbors) > 0 {
-		index := rand.Intn(len(neighbors))
-		row2 := neighbors[index][0]
-		col2 := neighbors[index][1]
+	for row := 0; row < m.height; row++ {
+		for col := 0; col < m.width; col++ {
+			options := make([][]int, 0, 2)
+			for _, d := range [][]int{vertical, horizontal} {
+				if m.inMaze(row+d[0], col+d[1]) {
+					options = append(options, d)
+				}
+			}
+			if len(options) > 0 {
+				d := options[rand.Intn(len(options))]
+				m.setWall(row, col, row+d[0], col+d[1], true)
+			}
+		}
+	}
+	return nil
+}
+
+// createSidewinderMaze generates a new maze using the sidewinder algorithm.
+// First, it fills the maze with walls.
+// Then it goes through each row in the horizontal direction of the bias, building runs of connected nodes.
+// At each node, it randomly either extends the run or ends it by removing the wall in the vertical direction of the bias
+// from a random node in the run.
+// The row on the side the bias points to is a single corridor because it can't remove walls vertically.
+func createSidewinderMaze(m *maze, bias string) error {
+	vertical, horizontal, err := biasDirections(bias)
+	if err != nil {
+		return err
+	}
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	for row := 0; row < m.height; row++ {
+		run := make([]int, 0)
+		for i := 0; i < m.width; i++ {
+			// Walk the row from the opposite side so runs end on the side the bias points to
+			col := i
+			if horizontal[1] < 0 {
+				col = m.width - 1 - i
+			}
+			run = append(run, col)
+
+			atHorizontalEdge := !m.inMaze(row, col+horizontal[1])
+			atVerticalEdge := !m.inMaze(row+vertical[0], col)
+			if atHorizontalEdge || (!atVerticalEdge && rand.Intn(2) == 0) {
+				if !atVerticalEdge {
+					runCol := run[rand.Intn(len(run))]
+					m.setWall(row, runCol, row+vertical[0], runCol, true)
+				}
+				run = run[:0]
+			} else {
+				m.setWall(row, col, row, col+horizontal[1], true)
+			}
+		}
+	}
+	return nil
+}
+
+// Cell selection policies for createGrowingTreeMaze
+const (
+	// POLICY_NEWEST picks the most recently added node, which behaves like createDFSMaze
+	POLICY_NEWEST = "newest"
+	// POLICY_RANDOM picks a random node, which behaves like createPrimMaze
+	POLICY_RANDOM = "random"
+	// POLICY_OLDEST picks the node that has been waiting the longest
+	POLICY_OLDEST = "oldest"
+)
+
+// maxGrowWeight is the biggest weight of a policy, which keeps the total of the weights from overflowing
+const maxGrowWeight = 1000
+
+type growWeight struct {
+	policy string
+	weight int
+}
+
+// parseGrowPolicy reads a policy made of comma separated POLICY_ names, each optionally followed by a colon and a weight.
+// For example, "newest:75,random:25" picks the newest node 75% of the time and a random node 25% of the time.
+// A policy without a weight has a weight of 1, and no weight can be more than maxGrowWeight.
+func parseGrowPolicy(policy string) ([]growWeight, error) {
+	weights := make([]growWeight, 0)
+	for _, part := range strings.Split(policy, ",") {
+		name, weightText, hasWeight := strings.Cut(strings.TrimSpace(part), ":")
+		if name != POLICY_NEWEST && name != POLICY_RANDOM && name != POLICY_OLDEST {
+			return nil, mkErr("invalid growing tree policy \"" + name + "\"")
+		}
+		weight := 1
+		if hasWeight {
+			var err error
+			weight, err = strconv.Atoi(weightText)
+			if err != nil || weight <= 0 || weight > maxGrowWeight {
+				return nil, mkErr("invalid growing tree policy weight \"" + weightText + "\"")
+			}
+		}
+		weights = append(weights, growWeight{policy: name, weight: weight})
+	}
+	return weights, nil
+}
+
+// pickGrowPolicy randomly picks one of the policies, based on their weights.
+func pickGrowPolicy(weights []growWeight) string {
+	total := 0
+	for _, w := range weights {
+		total += w.weight
+	}
+	pick := rand.Intn(total)
+	for _, w := range weights {
+		if pick < w.weight {
+			return w.policy
+		}
+		pick -= w.weight
+	}
+	return weights[len(weights)-1].policy
+}
+
+// createGrowingTreeMaze generates a new maze using the growing tree algorithm.
+// First, it fills the maze with walls.
+// Then it keeps a list of active nodes, starting with a random node.
+// Each step, it picks an active node using the policy (see parseGrowPolicy).
+// If that node has unvisited neighbors, it removes the wall to a random one and makes it active.
+// Otherwise, the node is removed from the list, and it continues until the list is empty.
+func createGrowingTreeMaze(m *maze, policy string) error {
+	weights, err := parseGrowPolicy(policy)
+	if err != nil {
+		return err
+	}
+	// Wipe the maze, filling with all walls
+	m.setAllWalls(true)
+
+	visited := m.makeVisited()
+	start := m.randomNode()
+	visited[start] = true
+	active := []int{start}
+
+	for len(active) > 0 {
+		var i int
+		switch pickGrowPolicy(weights) {
+		case POLICY_NEWEST:
+			i = len(active) - 1
+		case POLICY_RANDOM:
+			i = rand.Intn(len(active))
+		case POLICY_OLDEST:
+			i = 0
+		}
+		index := active[i]
+
+		unvisited := make([]int, 0)
+		for _, n := range m.neighbors(index) {
+			if !visited[n] {
+				unvisited = append(unvisited, n)
+			}
+		}
+
+		if len(unvisited) == 0 {
+			// Keep the order of the list so newest and oldest still make sense
+			active = append(active[:i], active[i+1:]...)
+			continue
+		}
+		n := unvisited[rand.Intn(len(unvisited))]
+		m.setWallIndex(index, n, true)
+		visited[n] = true
+		active = append(active, n)
+	}
+	return nil
+}
+
+// Orientations of the walls recorded by createDivisionMaze
+const (
+	DIVIDE_HORIZONTAL = iota
+	DIVIDE_VERTICAL
+)
+
+// createDivisionMaze generates a new maze using recursive division.
+// First, it removes every wall, which is the same as GEN_NONE.
+// Then it splits the maze into two chambers with a straight wall that has a single gap in it.
+// Each chamber is split the same way until the chambers are only one node wide.
+// Every wall is recorded in m.divisions as {orientation, row, col, length, gap} so it can be animated later.
+// - A horizontal wall runs along the bottom of (row, col) to (row, col+length-1), with the gap in column col+gap.
+// - A vertical wall runs along the right of (row, col) to (row+length-1, col), with the gap in row row+gap.
+// A gap of -1 means the wall has no gap, which happens when braidMaze opens part of a wall (see openDivision).
+func createDivisionMaze(m *maze) {
+	// Wipe the maze, removing all walls
+	m.setAllWalls(false)
+	m.divisions = make([][]int, 0)
+	divideChamber(m, 0, 0, m.height, m.width)
+}
+
+// divideChamber splits the chamber with its top left node at (row, col) and recurses on both halves.
+func divideChamber(m *maze, row int, col int, height int, width int) {
+	if height < 2 || width < 2 {
+		return
+	}
+
+	// Cut across the longer side so chambers stay roughly square
+	horizontal := height > width || (height == width && rand.Intn(2) == 0)
+	if horizontal {
+		wallRow := row + rand.Intn(height-1)
+		gap := rand.Intn(width)
+		for i := 0; i < width; i++ {
+			if i != gap {
+				m.setWall(wallRow, col+i, wallRow+1, col+i, false)
+			}
+		}
+		m.divisions = append(m.divisions, []int{DIVIDE_HORIZONTAL, wallRow, col, width, gap})
+
+		divideChamber(m, row, col, wallRow-row+1, width)
+		divideChamber(m, wallRow+1, col, row+height-wallRow-1, width)
+	} else {
+		wallCol := col + rand.Intn(width-1)
+		gap := rand.Intn(height)
+		for i := 0; i < height; i++ {
+			if i != gap {
+				m.setWall(row+i, wallCol, row+i, wallCol+1, false)
+			}
+		}
+		m.divisions = append(m.divisions, []int{DIVIDE_VERTICAL, row, wallCol, height, gap})
+
+		divideChamber(m, row, col, height, wallCol-col+1)
+		divideChamber(m, row, wallCol+1, height, col+width-wallCol-1)
+	}
+}
+
+// openDivision removes the wall between index1 and index2 from the walls recorded by createDivisionMaze, so the animation doesn't add it back.
+// The division it belongs to is split into the parts on either side of it, which keep the original gap if it is on their side.
+func (m *maze) openDivision(index1 int, index2 int) {
+	if index1 > index2 {
+		index1, index2 = index2, index1
+	}
+	row, col := getMazeCoords(m, index1)
+	// Nodes in the same row are split by a vertical wall, and nodes in the same column by a horizontal wall
+	orientation, along, across := DIVIDE_VERTICAL, row, col
+	if index2-index1 != 1 {
+		orientation, along, across = DIVIDE_HORIZONTAL, col, row
+	}
+	for i, d := range m.divisions {
+		// The start of the wall along its length, and where it is across the other way
+		start, at := d[1], d[2]
+		if orientation == DIVIDE_HORIZONTAL {
+			start, at = d[2], d[1]
+		}
+		pos := along - start
+		if d[0] != orientation || at != across || pos < 0 || pos >= d[3] || pos == d[4] {
+			continue
+		}
+
+		parts := make([][]int, 0, 2)
+		if pos > 0 {
+			gap := d[4]
+			if gap > pos {
+				gap = -1
+			}
+			parts = append(parts, []int{orientation, d[1], d[2], pos, gap})
+		}
+		if pos < d[3]-1 {
+			gap := d[4] - pos - 1
+			if d[4] < pos {
+				gap = -1
+			}
+			after := []int{orientation, d[1], d[2] + pos + 1, d[3] - pos - 1, gap}
+			if orientation == DIVIDE_VERTICAL {
+				after[1], after[2] = d[1]+pos+1, d[2]
+			}
+			parts = append(parts, after)
+		}
+		m.divisions = append(m.divisions[:i], append(parts, m.divisions[i+1:]...)...)
+		return
+	}
+}
+
+// passages returns the number of ways out of the node at index, which are walls that have been removed and tunnels
+func (m *maze) passages(index int) int {
+	return len(m.g.nodes[index].neighbors)
+}
+
+// braidMaze removes percent of the dead ends in the maze, which adds loops to it.
+// A dead end is a node with only one passage out of it.
+// Each dead end is removed by removing one of its walls, preferring walls that also lead into a dead end.
+// This means that one removed wall can remove two dead ends.
+func braidMaze(m *maze, percent int) {
+	deadEnds := make([]int, 0)
+	for index := range m.g.nodes {
+		if m.passages(index) == 1 {
+			deadEnds = append(deadEnds, index)
+		}
+	}
+	rand.Shuffle(len(deadEnds), func(i, j int) {
+		deadEnds[i], deadEnds[j] = deadEnds[j], deadEnds[i]
+	})
+
+	target := len(deadEnds) * percent / 100
+	removed := 0
+	for _, d := range deadEnds {
+		if removed >= target {
+			break
+		}
+		// An earlier removal may have already fixed this dead end
+		if m.passages(d) != 1 {
+			continue
+		}
+
+		walled := make([]int, 0)
+		alsoDeadEnds := make([]int, 0)
+		for _, n := range m.neighbors(d) {
+			// Opening a wall of a node with a tunnel under it would open the tunnel
+			if _, crossed := m.crossings[n]; crossed {
+				continue
+			}
+			if !m.g.hasEdge(d, n) {
+				walled = append(walled, n)
+				if m.passages(n) == 1 {
+					alsoDeadEnds = append(alsoDeadEnds, n)
+				}
+			}
+		}
+		if len(walled) == 0 {
+			continue
+		}
+		if len(alsoDeadEnds) > 0 {
+			walled = alsoDeadEnds
+			removed++
+		}
+		n := walled[rand.Intn(len(walled))]
+		m.setWallIndex(d, n, true)
+		if m.divisions != nil {
+			m.openDivision(d, n)
+		}
+		removed++
+	}
+}
+
+// connectNodes removes the fewest walls needed to make a path from index1 to index2 and returns how many it removed.
+// If they are already connected, nothing is removed.
+// It finds the path with a 0-1 BFS, where moving through a passage costs 0 and moving through a wall costs 1.
+// Nodes are handled one cost level at a time: moves that cost 0 stay in the current level, and moves that cost 1 go to the next.
+func connectNodes(m *maze, index1 int, index2 int) int {
+	// Check which component each node is in first, so connected nodes don't need a search
+	sets := makeDisjointSet(len(m.g.nodes))
+	for _, n := range m.g.nodes {
+		for _, adj := range n.neighbors {
+			sets.union(n.index, adj.n.index)
+		}
+	}
+	if sets.find(index1) == sets.find(index2) {
+		return 0
+	}
+
+	costs := make([]int, len(m.g.nodes), len(m.g.nodes))
+	parents := make([]int, len(m.g.nodes), len(m.g.nodes))
+	for i := range costs {
+		costs[i] = -1
+	}
+	costs[index1] = 0
+	parents[index1] = -1
+
+	current := []int{index1}
+	// Stop once index2 has been reached for the cost of the level, whether it was through a wall or a passage
+	for level := 0; len(current) > 0 && (costs[index2] == -1 || costs[index2] > level); level++ {
+		next := make([]int, 0)
+		for i := 0; i < len(current); i++ {
+			index := current[i]
+			// Skip nodes that were reached more cheaply after being queued
+			if costs[index] != level {
+				continue
+			}
+			// Edges (including tunnels and portals) cost nothing to go through, and walls cost one to remove
+			moves := make([]int, 0, 6)
+			for _, adj := range m.g.nodes[index].neighbors {
+				moves = append(moves, adj.n.index)
+			}
+			for _, n := range m.neighbors(index) {
+				if !m.g.hasEdge(index, n) {
+					moves = append(moves, n)
+				}
+			}
+			for _, n := range moves {
+				cost := level + 1
+				if m.g.hasEdge(index, n) {
+					cost = level
+				}
+				if costs[n] == -1 || cost < costs[n] {
+					costs[n] = cost
+					parents[n] = index
+					if cost == level {
+						current = append(current, n)
+					} else {
+						next = append(next, n)
+					}
+				}
+			}
+		}
+		current = next
+	}
 
-		if !(*visited)[row2][col2] {
-			m.setWall(row, col, row2, col2, true)
-			createDFSMazeRecursive(m, row2, col2, visited)
+	// Backtrack from index2, removing every wall on the way
+	removed := 0
+	for i := index2; parents[i] != -1; i = parents[i] {
+		if !m.g.hasEdge(i, parents[i]) {
+			m.setWallIndex(i, parents[i], true)
+			removed++
 		}
-		neighbors = append(neighbors[:index], neighbors[index+1:]...)
 	}
+	return removed
 }
 
 func (m *maze) fillPath(path []int, val int) {
@@ -0,0 +1,892 @@
+package maze
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// countEdges returns the number of undirected edges in the maze's graph.
+func countEdges(m *maze) int {
+	edges := 0
+	for _, n := range m.g.nodes {
+		edges += len(n.neighbors)
+	}
+	return edges / 2
+}
+
+// isPerfect returns true if every node can be reached from every other node by exactly one path.
+// This is the case when the graph is connected and has one less edge than it has nodes.
+// Masked nodes are not counted.
+func isPerfect(m *maze) bool {
+	if countEdges(m) != m.openNodes()-1 {
+		return false
+	}
+	ok, path, _ := bfsIterative(&m.g, -1, m.firstNode())
+	return !ok && len(*path) == m.openNodes()
+}
+
+func TestPrimMaze(t *testing.T) {
+	for _, size := range [][]int{{2, 2}, {3, 7}, {40, 25}} {
+		m, err := makeMaze(size[0], size[1], GEN_PRIM, GenOptions{})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Prim's maze of size %v is not perfect", size)
+	}
+}
+
+func TestKruskalMaze(t *testing.T) {
+	for _, size := range [][]int{{2, 2}, {3, 7}, {40, 25}} {
+		m, err := makeMaze(size[0], size[1], GEN_KRUSKAL, GenOptions{})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Kruskal's maze of size %v is not perfect", size)
+	}
+}
+
+func TestDisjointSet(t *testing.T) {
+	d := makeDisjointSet(5)
+	assert.True(t, d.union(0, 1))
+	assert.True(t, d.union(3, 4))
+	assert.False(t, d.union(1, 0))
+	assert.NotEqual(t, d.find(0), d.find(3))
+	assert.True(t, d.union(1, 4))
+	assert.Equal(t, d.find(0), d.find(3))
+	assert.NotEqual(t, d.find(2), d.find(3))
+}
+
+func TestUniformSpanningTreeMazes(t *testing.T) {
+	for _, alg := range []string{GEN_WILSON, GEN_ALDOUS_BRODER} {
+		for _, size := range [][]int{{2, 2}, {3, 7}, {40, 25}} {
+			m, err := makeMaze(size[0], size[1], alg, GenOptions{})
+			assert.Nil(t, err)
+			assert.True(t, isPerfect(m), "%v maze of size %v is not perfect", alg, size)
+		}
+
+		// A 2x2 grid has 4 spanning trees, each missing one of the 4 possible edges.
+		// Both algorithms should pick each of them about a quarter of the time.
+		counts := make([]int, 4)
+		for i := 0; i < 4000; i++ {
+			m, _ := makeMaze(2, 2, alg, GenOptions{})
+			switch {
+			case !m.g.hasEdge(0, 1):
+				counts[0]++
+			case !m.g.hasEdge(0, 2):
+				counts[1]++
+			case !m.g.hasEdge(1, 3):
+				counts[2]++
+			case !m.g.hasEdge(2, 3):
+				counts[3]++
+			}
+		}
+		for _, c := range counts {
+			assert.InDelta(t, 1000, c, 200, "%v is not uniform: %v", alg, counts)
+		}
+	}
+}
+
+func TestEllerMaze(t *testing.T) {
+	for _, size := range [][]int{{2, 2}, {3, 7}, {40, 25}} {
+		m, err := makeMaze(size[0], size[1], GEN_ELLER, GenOptions{})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Eller's maze of size %v is not perfect", size)
+	}
+}
+
+func TestStreamEllerMaze(t *testing.T) {
+	rows := 0
+	err := StreamEllerMaze(5, 20, func(row []MNode) error {
+		assert.Len(t, row, 5)
+		// Outer walls are always there
+		assert.True(t, row[0].Left)
+		assert.True(t, row[4].Right)
+		if rows == 0 {
+			for _, n := range row {
+				assert.True(t, n.Up)
+			}
+		}
+		rows++
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 20, rows)
+
+	// An error from the emitter stops generation
+	rows = 0
+	err = StreamEllerMaze(5, 20, func(row []MNode) error {
+		rows++
+		return mkErr("stop")
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 1, rows)
+}
+
+func TestDivisionMaze(t *testing.T) {
+	for _, size := range [][]int{{2, 2}, {3, 7}, {40, 25}} {
+		m, err := makeMaze(size[0], size[1], GEN_DIVISION, GenOptions{})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Recursive division maze of size %v is not perfect", size)
+
+		// Every wall in the maze was added by exactly one division
+		allEdges := size[0]*(size[1]-1) + size[1]*(size[0]-1)
+		assert.Equal(t, allEdges-countEdges(m), divisionWalls(m))
+	}
+
+	// Braiding opens walls that the divisions have to leave out
+	m, err := makeMaze(30, 20, GEN_DIVISION, GenOptions{Braid: 100})
+	assert.Nil(t, err)
+	assert.Equal(t, 30*19+20*29-countEdges(m), divisionWalls(m))
+	for _, d := range m.divisions {
+		for i := 0; i < d[3]; i++ {
+			if i == d[4] {
+				continue
+			}
+			if d[0] == DIVIDE_HORIZONTAL {
+				assert.False(t, m.g.hasEdge(getMazeIndex(m, d[1], d[2]+i), getMazeIndex(m, d[1]+1, d[2]+i)))
+			} else {
+				assert.False(t, m.g.hasEdge(getMazeIndex(m, d[1]+i, d[2]), getMazeIndex(m, d[1]+i, d[2]+1)))
+			}
+		}
+	}
+}
+
+// divisionWalls returns the number of walls recorded by createDivisionMaze
+func divisionWalls(m *maze) int {
+	walls := 0
+	for _, d := range m.divisions {
+		walls += d[3]
+		if d[4] != -1 {
+			walls--
+		}
+	}
+	return walls
+}
+
+func TestBiasedMazes(t *testing.T) {
+	for _, alg := range []string{GEN_BINARY_TREE, GEN_SIDEWINDER} {
+		for _, bias := range []string{BIAS_NE, BIAS_NW, BIAS_SE, BIAS_SW} {
+			m, err := makeMaze(30, 20, alg, GenOptions{Bias: bias})
+			assert.Nil(t, err)
+			assert.True(t, isPerfect(m), "%v maze with %v is not perfect", alg, bias)
+
+			// The row on the side the bias points to is a single corridor
+			row := 0
+			if bias == BIAS_SE || bias == BIAS_SW {
+				row = m.height - 1
+			}
+			for col := 0; col < m.width-1; col++ {
+				assert.True(t, m.g.hasEdge(getMazeIndex(m, row, col), getMazeIndex(m, row, col+1)), "%v maze with %v has a wall in row %v", alg, bias, row)
+			}
+		}
+
+		_, err := makeMaze(30, 20, alg, GenOptions{})
+		assert.NotNil(t, err, "%v should require a bias", alg)
+	}
+}
+
+func TestGrowingTreeMaze(t *testing.T) {
+	for _, policy := range []string{"newest", "random", "oldest", "newest:75,random:25", "oldest:1, random:3", "newest:1000,random:1"} {
+		m, err := makeMaze(30, 20, GEN_GROWING_TREE, GenOptions{Policy: policy})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Growing tree maze with policy %v is not perfect", policy)
+	}
+	for _, policy := range []string{"", "newest:", "newest:0", "newest:-5", "newest,middle", "newest:75;random:25", "newest:1001"} {
+		assert.NotNil(t, CheckGrowPolicy(policy), "Policy %v should be invalid", policy)
+	}
+	// Weights that add up to more than the biggest int are an error instead of a panic
+	_, err := makeMaze(10, 10, GEN_GROWING_TREE, GenOptions{Policy: "newest:9223372036854775807,random:1"})
+	assert.NotNil(t, err)
+}
+
+func TestHuntAndKillMaze(t *testing.T) {
+	for _, size := range [][]int{{2, 2}, {3, 7}, {40, 25}} {
+		m, err := makeMaze(size[0], size[1], GEN_HUNT_KILL, GenOptions{})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Hunt-and-kill maze of size %v is not perfect", size)
+	}
+}
+
+func countDeadEnds(m *maze) int {
+	deadEnds := 0
+	for index := range m.g.nodes {
+		if m.passages(index) == 1 {
+			deadEnds++
+		}
+	}
+	return deadEnds
+}
+
+func TestBraidMaze(t *testing.T) {
+	m, _ := makeMaze(30, 20, GEN_DFS, GenOptions{Braid: 0})
+	assert.True(t, isPerfect(m))
+
+	m, _ = makeMaze(30, 20, GEN_DFS, GenOptions{Braid: 100})
+	assert.Equal(t, 0, countDeadEnds(m))
+	ok, _, _ := bfsIterative(&m.g, NODE_GOAL, 0)
+	assert.True(t, ok)
+
+	m, _ = makeMaze(30, 20, GEN_PRIM, GenOptions{})
+	before := countDeadEnds(m)
+	braidMaze(m, 50)
+	after := countDeadEnds(m)
+	assert.InDelta(t, before/2, after, 1, "Braiding half of %v dead ends left %v", before, after)
+}
+
+func TestConnectNodes(t *testing.T) {
+	// With every wall in place, the shortest path needs one wall removed per step
+	m := initMaze(20, 30)
+	m.setAllWalls(true)
+	assert.Equal(t, 20+30-2, connectNodes(m, 0, len(m.g.nodes)-1))
+	ok, _, _ := bfsIterative(&m.g, NODE_GOAL, 0)
+	assert.False(t, ok)
+	m.setSquare(19, 29, NODE_GOAL)
+	ok, _, _ = bfsIterative(&m.g, NODE_GOAL, 0)
+	assert.True(t, ok)
+	assert.Equal(t, 0, connectNodes(m, 0, len(m.g.nodes)-1))
+
+	// Random mazes are always solvable
+	for i := 0; i < 20; i++ {
+		res, err := MakeSolveMaze(30, 20, GEN_RAND, GenOptions{Density: 40, Solvable: true}, SOLVE_BFS_SINGLE, 0)
+		assert.Nil(t, err)
+		assert.GreaterOrEqual(t, res.Carved, 0)
+	}
+}
+
+func TestHexMaze(t *testing.T) {
+	// Every side of a hexagon is shared with the opposite side of its neighbor
+	m := initHexMaze(7, 6)
+	for index := range m.g.nodes {
+		for side, n := range m.sides(index) {
+			if n != -1 {
+				assert.Equal(t, index, m.sides(n)[(side+3)%6], "Node %v side %v", index, side)
+			}
+		}
+	}
+
+	for _, alg := range []string{GEN_DFS, GEN_PRIM, GEN_KRUSKAL, GEN_WILSON, GEN_ALDOUS_BRODER, GEN_HUNT_KILL, GEN_GROWING_TREE} {
+		m, err := makeMaze(20, 15, alg, GenOptions{Shape: SHAPE_HEX, Policy: POLICY_NEWEST})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Hex %v maze is not perfect", alg)
+		res, err := MakeSolveMaze(20, 15, alg, GenOptions{Shape: SHAPE_HEX, Policy: POLICY_NEWEST}, SOLVE_BFS_SINGLE, 0)
+		assert.Nil(t, err)
+		assert.Len(t, res.Cells, 20*15)
+		assert.Len(t, res.Cells[0].Walls, 6)
+	}
+	for _, alg := range []string{GEN_ELLER, GEN_DIVISION, GEN_BINARY_TREE, GEN_SIDEWINDER} {
+		_, err := makeMaze(20, 15, alg, GenOptions{Shape: SHAPE_HEX, Bias: BIAS_NE})
+		assert.NotNil(t, err, "%v should only work with square nodes", alg)
+	}
+}
+
+func TestPolarMaze(t *testing.T) {
+	m := initPolarMaze(10)
+	assert.Equal(t, 1, m.ringSize(0))
+	assert.Equal(t, 6, m.ringSize(1))
+	for index := range m.g.nodes {
+		row, col := getMazeCoords(m, index)
+		assert.Equal(t, index, getMazeIndex(m, row, col))
+		// Every side is shared with a side of its neighbor
+		for _, n := range m.sides(index) {
+			if n != -1 {
+				assert.Contains(t, m.sides(n), index, "Node %v is not a neighbor of %v", index, n)
+			}
+		}
+	}
+
+	for _, alg := range []string{GEN_DFS, GEN_PRIM, GEN_KRUSKAL, GEN_WILSON, GEN_HUNT_KILL} {
+		m, err := makeMaze(0, 15, alg, GenOptions{Shape: SHAPE_POLAR})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "Polar %v maze is not perfect", alg)
+	}
+}
+
+func TestNodeCount(t *testing.T) {
+	for _, shape := range []string{SHAPE_SQUARE, SHAPE_HEX, SHAPE_TRIANGLE, SHAPE_POLAR} {
+		m, err := makeMaze(20, 15, GEN_NONE, GenOptions{Shape: shape})
+		assert.Nil(t, err)
+		assert.Equal(t, len(m.g.nodes), NodeCount(20, 15, shape, 1), "Wrong number of %v nodes", shape)
+	}
+	m, err := makeMaze(20, 15, GEN_NONE, GenOptions{Levels: 3})
+	assert.Nil(t, err)
+	assert.Equal(t, len(m.g.nodes), NodeCount(20, 15, SHAPE_SQUARE, 3))
+	assert.Equal(t, 0, NodeCount(0, 15, SHAPE_SQUARE, 1))
+}
+
+func TestTriangleMaze(t *testing.T) {
+	// Every side of a triangle is shared with the same side of its neighbor
+	m := initTriangleMaze(7, 6)
+	for index := range m.g.nodes {
+		for side, n := range m.sides(index) {
+			if n != -1 && side == TRI_BASE {
+				assert.Equal(t, index, m.sides(n)[TRI_BASE], "Node %v side %v", index, side)
+			} else if n != -1 {
+				assert.Equal(t, index, m.sides(n)[1-side], "Node %v side %v", index, side)
+			}
+		}
+	}
+
+	for _, solveAlg := range []string{SOLVE_BFS_SINGLE, SOLVE_BFS_MULTI} {
+		res, err := MakeSolveMaze(20, 15, GEN_DFS, GenOptions{Shape: SHAPE_TRIANGLE}, solveAlg, 0)
+		assert.Nil(t, err)
+		assert.Len(t, res.Cells, 20*15)
+		assert.Len(t, res.Cells[0].Walls, 3)
+		assert.Equal(t, 20*15-1, (*res.Best)[0])
+	}
+	m, err := makeMaze(20, 15, GEN_DFS, GenOptions{Shape: SHAPE_TRIANGLE})
+	assert.Nil(t, err)
+	assert.True(t, isPerfect(m))
+}
+
+func TestLevelMaze(t *testing.T) {
+	m := initLevelMaze(3, 4, 5)
+	for index := range m.g.nodes {
+		sides := m.sides(index)
+		assert.Len(t, sides, 6)
+		for _, n := range sides {
+			if n != -1 {
+				assert.Contains(t, m.sides(n), index, "Node %v is not a neighbor of %v", index, n)
+			}
+		}
+	}
+	// Up and down don't cross between levels, but stairs do
+	assert.Equal(t, -1, m.sides(getMazeIndex(m, 4, 0))[SQUARE_UP])
+	assert.Equal(t, getMazeIndex(m, 0, 0), m.sides(getMazeIndex(m, 4, 0))[SQUARE_ABOVE])
+
+	m, err := makeMaze(20, 15, GEN_DFS, GenOptions{Levels: 3})
+	assert.Nil(t, err)
+	assert.True(t, isPerfect(m))
+
+	for _, solveAlg := range []string{SOLVE_DFS_MULTI, SOLVE_BFS_SINGLE, SOLVE_BFS_MULTI} {
+		res, err := MakeSolveMaze(20, 15, GEN_DFS, GenOptions{Levels: 3}, solveAlg, 0)
+		assert.Nil(t, err, "%v failed on a maze with levels", solveAlg)
+		assert.Nil(t, res.Nodes)
+		assert.Len(t, res.Levels, 3)
+		assert.Len(t, *res.Levels[2], 15)
+		// The goal is on the bottom level
+		assert.Equal(t, NODE_GOAL, (*res.Levels[2])[14][19].Val)
+	}
+	res, err := MakeSolveMaze(20, 15, GEN_RAND, GenOptions{Levels: 2, Density: 15, Solvable: true}, SOLVE_BFS_SINGLE, 0)
+	assert.Nil(t, err)
+	stairs := 0
+	for _, row := range *res.Levels[0] {
+		for _, n := range row {
+			if n.StairsDown {
+				stairs++
+			}
+		}
+	}
+	assert.Greater(t, stairs, 0)
+
+	_, err = makeMaze(20, 15, GEN_ELLER, GenOptions{Levels: 2})
+	assert.NotNil(t, err)
+}
+
+func TestWrapMaze(t *testing.T) {
+	m := initMaze(4, 5)
+	assert.Nil(t, m.setWrap(WRAP_BOTH))
+	for index := range m.g.nodes {
+		// With no edges, every node has 4 neighbors
+		assert.Len(t, m.neighbors(index), 4)
+		for side, n := range m.sides(index) {
+			// Up and down, and right and left, are opposites
+			assert.Equal(t, index, m.sides(n)[side^1], "Node %v side %v", index, side)
+		}
+	}
+
+	for _, wrap := range []string{WRAP_HORIZONTAL, WRAP_VERTICAL, WRAP_BOTH} {
+		m, err := makeMaze(20, 15, GEN_DFS, GenOptions{Wrap: wrap})
+		assert.Nil(t, err)
+		assert.True(t, isPerfect(m), "%v maze is not perfect", wrap)
+	}
+
+	// A maze with no walls has passages across every edge that wraps
+	m, _ = makeMaze(20, 15, GEN_NONE, GenOptions{Wrap: WRAP_HORIZONTAL})
+	nodes := *mazeToSlice(m)
+	assert.False(t, nodes[3][0].Left)
+	assert.False(t, nodes[3][19].Right)
+	assert.True(t, nodes[0][3].Up)
+
+	_, err := makeMaze(20, 15, GEN_DFS, GenOptions{Wrap: WRAP_BOTH, Shape: SHAPE_HEX})
+	assert.NotNil(t, err)
+	_, err = makeMaze(2, 15, GEN_DFS, GenOptions{Wrap: WRAP_HORIZONTAL})
+	assert.NotNil(t, err)
+}
+
+func TestWeaveMaze(t *testing.T) {
+	m, err := makeMaze(30, 20, GEN_DFS, GenOptions{Weave: 100})
+	assert.Nil(t, err)
+	assert.True(t, isPerfect(m))
+	assert.Greater(t, len(m.crossings), 0)
+
+	nodes := *mazeToSlice(m)
+	for index, vertical := range m.crossings {
+		row, col := getMazeCoords(m, index)
+		n := nodes[row][col]
+		// The passage over a tunnel goes across it
+		assert.Equal(t, vertical, n.UnderVertical)
+		assert.Equal(t, !vertical, n.UnderHorizontal)
+		assert.Equal(t, vertical, n.Up && n.Down && !n.Right && !n.Left)
+		// The nodes on either end of the tunnel are open towards it
+		if vertical {
+			assert.False(t, nodes[row-1][col].Down)
+			assert.False(t, nodes[row+1][col].Up)
+		} else {
+			assert.False(t, nodes[row][col-1].Right)
+			assert.False(t, nodes[row][col+1].Left)
+		}
+	}
+
+	// Solvers go through tunnels like any other passage
+	res, err := MakeSolveMaze(30, 20, GEN_DFS, GenOptions{Weave: 100}, SOLVE_BFS_SINGLE, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 30*20-1, (*res.Best)[0])
+}
+
+func TestMaskedMaze(t *testing.T) {
+	mask, err := ParseMask("XX......\n#......X\n........\n..XXXX..\n..X\n........\r\n\n")
+	assert.Nil(t, err)
+	assert.Len(t, mask, 6)
+	assert.Len(t, mask[4], 8)
+	assert.True(t, mask[0][0])
+	assert.True(t, mask[1][0])
+	assert.False(t, mask[4][7])
+
+	for _, alg := range []string{GEN_DFS, GEN_PRIM, GEN_KRUSKAL, GEN_WILSON, GEN_ALDOUS_BRODER, GEN_HUNT_KILL, GEN_GROWING_TREE} {
+		for _, shape := range []string{SHAPE_SQUARE, SHAPE_HEX, SHAPE_TRIANGLE} {
+			m, err := makeMaze(0, 0, alg, GenOptions{Mask: mask, Shape: shape, Policy: POLICY_NEWEST})
+			assert.Nil(t, err)
+			assert.True(t, isPerfect(m), "Masked %v %v maze is not perfect", shape, alg)
+			for index := range m.g.nodes {
+				if m.isMasked(index) {
+					assert.Len(t, m.g.nodes[index].neighbors, 0)
+				}
+			}
+		}
+	}
+
+	// The start and goal can't be masked
+	_, err = MakeSolveMaze(0, 0, GEN_DFS, GenOptions{Mask: mask}, SOLVE_BFS_SINGLE, 0)
+	assert.NotNil(t, err)
+	res, err := MakeSolveMaze(0, 0, GEN_DFS, GenOptions{Mask: mask}, SOLVE_DFS_MULTI, 2)
+	assert.Nil(t, err)
+	nodes := *res.Nodes
+	assert.True(t, nodes[0][0].Masked)
+	assert.Equal(t, NODE_GOAL, nodes[5][7].Val)
+	assert.True(t, nodes[2][2].Down, "Nodes next to a masked node are walled off")
+
+	// Masks can't split the maze in two
+	mask, _ = ParseMask("....\nXXXX\n....")
+	_, err = makeMaze(0, 0, GEN_DFS, GenOptions{Mask: mask})
+	assert.NotNil(t, err)
+}
+
+func TestPNGMask(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 5, 4))
+	for x := 0; x < 5; x++ {
+		for y := 0; y < 4; y++ {
+			img.SetGray(x, y, color.Gray{Y: 255})
+		}
+	}
+	img.SetGray(1, 2, color.Gray{Y: 0})
+	buf := new(bytes.Buffer)
+	assert.Nil(t, png.Encode(buf, img))
+
+	mask, err := ParsePNGMask(buf)
+	assert.Nil(t, err)
+	assert.Len(t, mask, 4)
+	assert.Len(t, mask[0], 5)
+	assert.True(t, mask[2][1])
+	assert.False(t, mask[1][2])
+}
+
+func TestPortals(t *testing.T) {
+	pairs, err := ParsePortalPairs("0-599, 20-45")
+	assert.Nil(t, err)
+	assert.Equal(t, [][]int{{0, 599}, {20, 45}}, pairs)
+	for _, text := range []string{"0", "0-1-2", "a-3", "1-2,"} {
+		_, err := ParsePortalPairs(text)
+		assert.NotNil(t, err, "%v should be invalid", text)
+	}
+
+	// Generators leave portals in place
+	for _, alg := range []string{GEN_DFS, GEN_PRIM, GEN_KRUSKAL, GEN_WILSON, GEN_ELLER, GEN_DIVISION, GEN_SIDEWINDER, GEN_NONE} {
+		m, err := makeMaze(30, 20, alg, GenOptions{PortalPairs: pairs, Portals: 3, Bias: BIAS_NE})
+		assert.Nil(t, err)
+		assert.Len(t, m.portals, 5)
+		for _, p := range m.portals {
+			assert.Equal(t, EDGE_PORTAL, m.g.edgeKind(p[0], p[1]), "%v removed a portal", alg)
+		}
+	}
+
+	// A portal from the start to the goal is the whole solution
+	res, err := MakeSolveMaze(30, 20, GEN_DFS, GenOptions{PortalPairs: pairs}, SOLVE_BFS_SINGLE, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{599, 0}, *res.Best)
+	nodes := *res.Nodes
+	assert.Equal(t, 1, nodes[0][0].Portal)
+	assert.Equal(t, 1, nodes[19][29].Portal)
+	assert.Equal(t, 2, nodes[0][20].Portal)
+	assert.Equal(t, 0, nodes[0][1].Portal)
+
+	for _, bad := range [][]int{{0, 1}, {0, 30}, {3, 3}, {0, 600}, {-1, 5}} {
+		_, err := makeMaze(30, 20, GEN_DFS, GenOptions{PortalPairs: [][]int{bad}})
+		assert.NotNil(t, err, "Portal %v should be invalid", bad)
+	}
+	_, err = makeMaze(30, 20, GEN_DFS, GenOptions{PortalPairs: [][]int{{0, 50}, {50, 70}}})
+	assert.NotNil(t, err, "Nodes can only have one portal")
+}
+
+func TestOneWay(t *testing.T) {
+	for _, shape := range []string{SHAPE_SQUARE, SHAPE_HEX, SHAPE_TRIANGLE, SHAPE_POLAR} {
+		opts := GenOptions{Shape: shape, Braid: 50, OneWay: 100}
+		m, err := makeMaze(20, 10, GEN_DFS, opts)
+		assert.Nil(t, err)
+		makeOneWay(m, opts.OneWay, 0)
+		// Every passage goes one way, but the goal can still be reached
+		for index := range m.g.nodes {
+			for _, n := range m.neighbors(index) {
+				assert.False(t, m.g.hasEdge(index, n) && m.g.hasEdge(n, index), "%v passage %v-%v goes both ways", shape, index, n)
+			}
+		}
+		assert.NotEmpty(t, shortestPath(&m.g, 0, m.goal), "%v goal can't be reached", shape)
+	}
+
+	res, err := MakeSolveMaze(20, 10, GEN_KRUSKAL, GenOptions{OneWay: 100}, SOLVE_BFS_SINGLE, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, (*res.Best)[len(*res.Best)-1])
+	// One-way passages are not walls, and the nodes on either side point opposite ways
+	nodes := *res.Nodes
+	for row := range nodes {
+		for col := 0; col < len(nodes[row])-1; col++ {
+			left, right := nodes[row][col], nodes[row][col+1]
+			assert.Equal(t, left.Right, right.Left)
+			if !left.Right {
+				assert.NotEqual(t, ONEWAY_NONE, left.OneWayRight)
+				assert.Equal(t, left.OneWayRight == ONEWAY_OUT, right.OneWayLeft == ONEWAY_IN)
+			}
+		}
+	}
+
+	res, err = MakeSolveMaze(20, 10, GEN_DFS, GenOptions{}, SOLVE_BFS_SINGLE, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, ONEWAY_NONE, (*res.Nodes)[0][0].OneWayRight)
+}
+
+func TestTerrain(t *testing.T) {
+	for _, shape := range []string{SHAPE_SQUARE, SHAPE_HEX, SHAPE_POLAR} {
+		m, err := makeMaze(20, 10, GEN_PRIM, GenOptions{Shape: shape, Terrain: 40})
+		assert.Nil(t, err)
+		painted := 0
+		for index, n := range m.g.nodes {
+			if m.terrainAt(index) != TERRAIN_PLAIN {
+				painted++
+			}
+			assert.Equal(t, terrainCosts[m.terrainAt(index)], n.cost)
+			// The weight of an edge is the cost of the node it leads to
+			for _, adj := range n.neighbors {
+				assert.Equal(t, adj.n.cost, adj.weight)
+			}
+		}
+		assert.Equal(t, len(m.g.nodes)*40/100, painted, "%v maze has the wrong amount of terrain", shape)
+	}
+
+	// Without terrain, every move costs 1
+	res, err := MakeSolveMaze(20, 10, GEN_DFS, GenOptions{}, SOLVE_BFS_SINGLE, 0)
+	assert.Nil(t, err)
+	assert.Equal(t, len(*res.Best)-1, res.Cost)
+	assert.Equal(t, TERRAIN_PLAIN, (*res.Nodes)[0][0].Terrain)
+
+	res, err = MakeSolveMaze(20, 10, GEN_NONE, GenOptions{Terrain: 100}, SOLVE_BFS_SINGLE, 0)
+	assert.Nil(t, err)
+	assert.NotEqual(t, TERRAIN_PLAIN, (*res.Nodes)[0][0].Terrain)
+	assert.GreaterOrEqual(t, res.Cost, len(*res.Best)-1)
+}
+
+func TestAStar(t *testing.T) {
+	// A perfect maze only has one solution
+	m, err := makeMaze(30, 20, GEN_DFS, GenOptions{})
+	assert.Nil(t, err)
+	_, _, bfsBest := bfs(&m.g, NODE_GOAL, 0)
+	for _, heuristic := range []string{HEURISTIC_MANHATTAN, HEURISTIC_EUCLIDEAN, HEURISTIC_ZERO} {
+		ok, _, best, err := astar(m, 0, heuristic)
+		assert.Nil(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, *bfsBest, *best)
+	}
+
+	// Without walls, an informed search goes straight to the goal
+	m, err = makeMaze(30, 20, GEN_NONE, GenOptions{})
+	assert.Nil(t, err)
+	_, informed, best, err := astar(m, 0, HEURISTIC_MANHATTAN)
+	assert.Nil(t, err)
+	assert.Len(t, *best, 30+20-1)
+	_, uninformed, _, err := astar(m, 0, HEURISTIC_ZERO)
+	assert.Nil(t, err)
+	assert.Less(t, len((*informed)[0]), len((*uninformed)[0]))
+
+	// Terrain makes the cheapest path cost less than the shortest path
+	for i := 0; i < 10; i++ {
+		m, err = makeMaze(30, 20, GEN_DFS, GenOptions{Braid: 100, Terrain: 50})
+		assert.Nil(t, err)
+		_, _, shortest := bfs(&m.g, NODE_GOAL, 0)
+		_, _, cheapest, err := astar(m, 0, HEURISTIC_ZERO)
+		assert.Nil(t, err)
+		_, _, euclidean, err := astar(m, 0, HEURISTIC_EUCLIDEAN)
+		assert.Nil(t, err)
+		assert.LessOrEqual(t, pathCost(&m.g, *cheapest), pathCost(&m.g, *shortest))
+		assert.Equal(t, pathCost(&m.g, *cheapest), pathCost(&m.g, *euclidean))
+	}
+
+	for _, shape := range []string{SHAPE_HEX, SHAPE_TRIANGLE, SHAPE_POLAR} {
+		res, err := MakeSolveMaze(20, 10, GEN_PRIM, GenOptions{Shape: shape, Heuristic: HEURISTIC_EUCLIDEAN}, SOLVE_ASTAR, 0)
+		assert.Nil(t, err)
+		assert.Equal(t, 0, (*res.Best)[len(*res.Best)-1])
+	}
+
+	// The distances never guess more than the number of steps left, whatever the shape
+	for _, shape := range []string{SHAPE_SQUARE, SHAPE_HEX, SHAPE_TRIANGLE, SHAPE_POLAR} {
+		m, err := makeMaze(20, 10, GEN_NONE, GenOptions{Shape: shape})
+		assert.Nil(t, err)
+		steps := map[int]int{m.goal: 0}
+		for layer := []int{m.goal}; len(layer) > 0; {
+			next := make([]int, 0)
+			for _, index := range layer {
+				for _, n := range m.neighbors(index) {
+					if _, ok := steps[n]; !ok {
+						steps[n] = steps[index] + 1
+						next = append(next, n)
+					}
+				}
+			}
+			layer = next
+		}
+		for _, heuristic := range []string{HEURISTIC_MANHATTAN, HEURISTIC_EUCLIDEAN} {
+			guess, err := m.makeHeuristic(heuristic)
+			assert.Nil(t, err)
+			for index := range m.g.nodes {
+				assert.LessOrEqual(t, guess(index), float64(steps[index])+1e-9, "%v %v node %v is closer than the guess", heuristic, shape, index)
+			}
+		}
+	}
+
+	// Shortcuts through tunnels, portals and edges that wrap still leave A* with the cheapest path
+	for _, opts := range []GenOptions{
+		{Braid: 100, Weave: 100},
+		{Braid: 100, Portals: 5},
+		{Braid: 100, Wrap: WRAP_BOTH},
+		{Braid: 100, Terrain: 50, OneWay: 20, Levels: 2},
+		{Braid: 100, Terrain: 50, Shape: SHAPE_HEX},
+		{Braid: 100, Terrain: 50, Shape: SHAPE_TRIANGLE},
+		{Braid: 100, Terrain: 50, Shape: SHAPE_POLAR},
+	} {
+		for i := 0; i < 5; i++ {
+			m, err := makeMaze(30, 20, GEN_DFS, opts)
+			assert.Nil(t, err)
+			_, _, _, cost := dijkstra(&m.g, NODE_GOAL, 0)
+			for _, heuristic := range []string{HEURISTIC_MANHATTAN, HEURISTIC_EUCLIDEAN} {
+				_, _, best, err := astar(m, 0, heuristic)
+				assert.Nil(t, err)
+				assert.Equal(t, cost, pathCost(&m.g, *best), "%v with %+v", heuristic, opts)
+			}
+		}
+	}
+	_, err = MakeSolveMaze(20, 10, GEN_PRIM, GenOptions{Heuristic: "HEURISTIC_GUESS"}, SOLVE_ASTAR, 0)
+	assert.NotNil(t, err)
+}
+
+func TestDijkstra(t *testing.T) {
+	// Without terrain, the cheapest path is the shortest path
+	m, err := makeMaze(30, 20, GEN_DFS, GenOptions{Braid: 100})
+	assert.Nil(t, err)
+	_, _, shortest := bfs(&m.g, NODE_GOAL, 0)
+	ok, paths, cheapest, cost := dijkstra(&m.g, NODE_GOAL, 0)
+	assert.True(t, ok)
+	assert.Len(t, *cheapest, len(*shortest))
+	assert.Equal(t, len(*cheapest)-1, cost)
+	assert.Equal(t, 0, (*paths)[0][0])
+
+	for i := 0; i < 10; i++ {
+		m, err = makeMaze(30, 20, GEN_DFS, GenOptions{Braid: 100, Terrain: 50})
+		assert.Nil(t, err)
+		_, _, shortest = bfs(&m.g, NODE_GOAL, 0)
+		ok, _, cheapest, cost = dijkstra(&m.g, NODE_GOAL, 0)
+		assert.True(t, ok)
+		assert.Equal(t, pathCost(&m.g, *cheapest), cost)
+		assert.LessOrEqual(t, cost, pathCost(&m.g, *shortest))
+		// A* without a heuristic is the same search
+		_, _, zero, _ := astar(m, 0, HEURISTIC_ZERO)
+		assert.Equal(t, pathCost(&m.g, *zero), cost)
+	}
+
+	// The cheapest path goes around a node that costs more than the detour
+	m = initMaze(2, 3)
+	m.g.setNode(2, NODE_GOAL)
+	m.g.nodes[1].cost = 10
+	m.setAllWalls(false)
+	ok, _, cheapest, cost = dijkstra(&m.g, NODE_GOAL, 0)
+	assert.True(t, ok)
+	assert.Equal(t, []int{2, 5, 4, 3, 0}, *cheapest)
+	assert.Equal(t, 4, cost)
+
+	res, err := MakeSolveMaze(30, 20, GEN_PRIM, GenOptions{Terrain: 30}, SOLVE_DIJKSTRA, 0)
+	assert.Nil(t, err)
+	assert.GreaterOrEqual(t, res.Cost, len(*res.Best)-1)
+}
+
+func TestBidirectionalBFS(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		m, err := makeMaze(30, 20, GEN_DFS, GenOptions{Braid: 50})
+		assert.Nil(t, err)
+		makeOneWay(m, 30, 0)
+		_, _, shortest := bfsIterative(&m.g, NODE_GOAL, 0)
+		ok, paths, best := bfsBidirectional(&m.g, 0, m.goal)
+		assert.True(t, ok)
+		assert.Len(t, *paths, 2)
+		assert.Len(t, *best, len(*shortest))
+		assert.Equal(t, m.goal, (*best)[0])
+		assert.Equal(t, 0, (*best)[len(*best)-1])
+		// Every step follows an edge in the direction it is walked
+		for j := len(*best) - 1; j > 0; j-- {
+			assert.True(t, m.g.hasEdge((*best)[j], (*best)[j-1]))
+		}
+	}
+
+	// Both searches cover less of an open maze than one search from the start
+	m, err := makeMaze(100, 100, GEN_NONE, GenOptions{})
+	assert.Nil(t, err)
+	_, visited, _ := bfsIterative(&m.g, NODE_GOAL, 0)
+	_, paths, _ := bfsBidirectional(&m.g, 0, m.goal)
+	assert.Less(t, len((*paths)[0])+len((*paths)[1]), len(*visited))
+	assert.NotContains(t, (*paths)[1], m.goal)
+
+	ok, _, best := bfsBidirectional(&m.g, m.goal, m.goal)
+	assert.True(t, ok)
+	assert.Equal(t, []int{m.goal}, *best)
+	ok, _, _ = bfsBidirectional(&initMaze(5, 5).g, 0, 24)
+	assert.False(t, ok)
+}
+
+func TestWallFollower(t *testing.T) {
+	// The left hand follows the top wall, and the right hand follows the left wall
+	m, err := makeMaze(3, 3, GEN_NONE, GenOptions{})
+	assert.Nil(t, err)
+	ok, walk, best, err := wallFollower(m, 0, true)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, []int{0, 1, 2, 5}, (*walk)[0])
+	assert.Equal(t, []int{8, 5, 2, 1, 0}, *best)
+	_, walk, best, err = wallFollower(m, 0, false)
+	assert.Nil(t, err)
+	assert.Equal(t, []int{0, 3, 6, 7}, (*walk)[0])
+	assert.Equal(t, []int{8, 7, 6, 3, 0}, *best)
+
+	// Without walls to follow, it turns the same way forever
+	m, err = makeMaze(5, 5, GEN_NONE, GenOptions{})
+	assert.Nil(t, err)
+	_, _, _, err = wallFollower(m, 12, true)
+	assert.NotNil(t, err)
+
+	// A perfect maze only has one solution, and following either wall finds it
+	for _, shape := range []string{SHAPE_SQUARE, SHAPE_HEX, SHAPE_TRIANGLE, SHAPE_POLAR} {
+		m, err := makeMaze(20, 10, GEN_PRIM, GenOptions{Shape: shape})
+		assert.Nil(t, err)
+		_, _, shortest := bfs(&m.g, NODE_GOAL, 0)
+		for _, leftHand := range []bool{true, false} {
+			ok, walk, best, err := wallFollower(m, 0, leftHand)
+			assert.Nil(t, err, "%v wall follower failed", shape)
+			assert.True(t, ok)
+			assert.Equal(t, *shortest, *best)
+			// Every step of the walk goes to a node next to the last one
+			for i := 1; i < len((*walk)[0]); i++ {
+				assert.Contains(t, m.neighbors((*walk)[0][i-1]), (*walk)[0][i])
+			}
+		}
+	}
+
+	// Tunnels are followed like passages
+	m, err = makeMaze(20, 20, GEN_DFS, GenOptions{Weave: 100})
+	assert.Nil(t, err)
+	_, _, shortest := bfs(&m.g, NODE_GOAL, 0)
+	_, _, best, err = wallFollower(m, 0, true)
+	assert.Nil(t, err)
+	assert.Equal(t, *shortest, *best)
+
+	// The sides of every node go clockwise on the page, where y points down and the angle to each neighbor goes up
+	for _, shape := range []string{SHAPE_SQUARE, SHAPE_HEX, SHAPE_TRIANGLE, SHAPE_POLAR} {
+		m, err := makeMaze(20, 10, GEN_NONE, GenOptions{Shape: shape})
+		assert.Nil(t, err)
+		for index := range m.g.nodes {
+			p := m.position(index)
+			angles := make([]float64, 0)
+			for _, side := range m.clockwise(index) {
+				if n := m.sides(index)[side]; n != -1 {
+					q := m.position(n)
+					angles = append(angles, math.Atan2(q[1]-p[1], q[0]-p[0]))
+				}
+			}
+			// Going around once, the angle only wraps back down once
+			descents := 0
+			for i := range angles {
+				if angles[(i+1)%len(angles)] < angles[i] {
+					descents++
+				}
+			}
+			assert.LessOrEqual(t, descents, 1, "%v node %v sides aren't clockwise", shape, index)
+		}
+	}
+
+	_, err = MakeSolveMaze(10, 10, GEN_NONE, GenOptions{}, SOLVE_WALL_FOLLOWER_RIGHT, 44)
+	assert.NotNil(t, err)
+	_, err = MakeSolveMaze(10, 10, GEN_DFS, GenOptions{Levels: 2}, SOLVE_WALL_FOLLOWER_LEFT, 0)
+	assert.NotNil(t, err)
+}
+
+func TestDeadEndFill(t *testing.T) {
+	// Only the solution is left in a perfect maze
+	for _, alg := range []string{GEN_DFS, GEN_PRIM, GEN_WILSON} {
+		m, err := makeMaze(30, 20, alg, GenOptions{})
+		assert.Nil(t, err)
+		_, _, shortest := bfs(&m.g, NODE_GOAL, 0)
+		ok, paths, best := deadEndFill(&m.g, 0, m.goal)
+		assert.True(t, ok)
+		assert.Equal(t, *shortest, *best)
+		// Every node is either filled once or on the solution
+		filled := 0
+		for _, path := range *paths {
+			filled += len(path)
+			assert.NotContains(t, path, 0)
+			assert.NotContains(t, path, m.goal)
+		}
+		assert.Equal(t, len(m.g.nodes), filled+len(*best))
+	}
+
+	// Loops are left over, along with the solution
+	m, err := makeMaze(30, 20, GEN_DFS, GenOptions{Braid: 100})
+	assert.Nil(t, err)
+	ok, _, best := deadEndFill(&m.g, 0, m.goal)
+	assert.True(t, ok)
+	assert.Contains(t, *best, 0)
+	for _, index := range *best {
+		assert.Greater(t, m.passages(index), 1)
+	}
+
+	// A maze with every wall has nothing to fill, and nothing connects the start to the goal
+	ok, _, _ = deadEndFill(&initMaze(5, 5).g, 0, 24)
+	assert.False(t, ok)
+
+	res, err := MakeSolveMaze(20, 20, GEN_KRUSKAL, GenOptions{Mask: [][]bool{
+		{true, false, false},
+		{false, false, false},
+		{false, false, true},
+	}}, SOLVE_DEAD_END_FILL, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 7, (*res.Best)[0])
+	assert.Equal(t, 1, (*res.Best)[len(*res.Best)-1])
+
+	// The nodes left over in a maze with loops have no cost to walk
+	res, err = MakeSolveMaze(30, 20, GEN_DFS, GenOptions{Braid: 100, Terrain: 50}, SOLVE_DEAD_END_FILL, 0)
+	assert.Nil(t, err)
+	assert.Zero(t, res.Cost)
+}
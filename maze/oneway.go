@@ -0,0 +1,97 @@
+package maze
+
+import "math/rand"
+
+// Directions of a one-way passage, from the point of view of one of its nodes
+const (
+	ONEWAY_NONE = iota
+	ONEWAY_OUT
+	ONEWAY_IN
+)
+
+// makeOneWay turns percent of the passages in the maze into one-way passages.
+// Passages on the path from startIndex to the goal only go towards the goal, so the goal can still be reached.
+// Every other passage goes in a random direction.
+// Only passages between nodes that are next to each other are changed, not tunnels, portals or stairs.
+func makeOneWay(m *maze, percent int, startIndex int) {
+	// Find the path to the goal first, following the directions of any edges that are already one-way
+	towardsGoal := make(map[int]int)
+	path := shortestPath(&m.g, startIndex, m.goal)
+	for i := 1; i < len(path); i++ {
+		towardsGoal[path[i-1]] = path[i]
+	}
+	// onPath returns true if the path to the goal goes from index1 to index2
+	onPath := func(index1 int, index2 int) bool {
+		next, ok := towardsGoal[index1]
+		return ok && next == index2
+	}
+
+	passages := make([][]int, 0)
+	for index := range m.g.nodes {
+		for side, n := range m.sides(index) {
+			if m.shape == SHAPE_SQUARE && side >= SQUARE_ABOVE {
+				break
+			}
+			if n > index && m.g.edgeKind(index, n) == EDGE_PASSAGE && m.g.hasEdge(n, index) {
+				passages = append(passages, []int{index, n})
+			}
+		}
+	}
+	rand.Shuffle(len(passages), func(i, j int) {
+		passages[i], passages[j] = passages[j], passages[i]
+	})
+
+	for _, p := range passages[:len(passages)*percent/100] {
+		from, to := p[0], p[1]
+		if onPath(to, from) || (!onPath(from, to) && rand.Intn(2) == 0) {
+			from, to = to, from
+		}
+		m.g.makeOneWay(from, to)
+	}
+}
+
+// shortestPath returns the nodes on the shortest path from start to goal, following the direction of every edge.
+// It is empty if there is no path.
+func shortestPath(g *graph, start int, goal int) []int {
+	parents := make([]int, len(g.nodes), len(g.nodes))
+	for i := range parents {
+		parents[i] = -1
+	}
+	parents[start] = start
+	queue := []int{start}
+	for len(queue) > 0 && parents[goal] == -1 {
+		index := queue[0]
+		queue = queue[1:]
+		for _, adj := range g.nodes[index].neighbors {
+			if parents[adj.n.index] == -1 {
+				parents[adj.n.index] = index
+				queue = append(queue, adj.n.index)
+			}
+		}
+	}
+	if parents[goal] == -1 {
+		return []int{}
+	}
+
+	path := []int{goal}
+	for i := goal; i != start; i = parents[i] {
+		path = append(path, parents[i])
+	}
+	// Reverse it so it starts at the start
+	for i, j := 0, len(path)-1; i < j; i, j = i+1, j-1 {
+		path[i], path[j] = path[j], path[i]
+	}
+	return path
+}
+
+// oneWay returns the direction of the passage between index and n, from the point of view of index
+func (m *maze) oneWay(index int, n int) int {
+	out, in := m.g.hasEdge(index, n), m.g.hasEdge(n, index)
+	switch {
+	case out && !in:
+		return ONEWAY_OUT
+	case in && !out:
+		return ONEWAY_IN
+	}
+	return ONEWAY_NONE
+}
@@ -0,0 +1,99 @@
+package maze
+
+import (
+	"math"
+	"sort"
+)
+
+// Sides of a polar node.
+// Nodes in polar mazes are in rings around a center node, so a row is a ring and a column is a position around it.
+// Columns increase clockwise, starting at the right of the center.
+// A node can have more than one outward neighbor when the next ring is split into more nodes.
+// They all come after POLAR_OUT, in clockwise order.
+const (
+	POLAR_IN = iota
+	POLAR_CW
+	POLAR_CCW
+	POLAR_OUT
+)
+
+// initPolarMaze makes a maze of rings around a single center node.
+// Rings are split into more nodes as they get bigger, so every node is about as wide as it is tall.
+// Each ring fills clockwise, then rings fill from the center out.
+// Polar mazes only have a height (the number of rings); the number of nodes in each ring depends on how far out it is.
+func initPolarMaze(rings int) *maze {
+	if rings < 2 {
+		return nil
+	}
+
+	ringStarts := polarRingStarts(rings)
+	var m maze
+	for i := 0; i < ringStarts[rings]; i++ {
+		m.g.addNode(NODE_EMPTY)
+	}
+	m.shape = SHAPE_POLAR
+	m.height = rings
+	m.levels = 1
+	m.width = ringStarts[rings] - ringStarts[rings-1]
+	m.ringStarts = ringStarts
+	return &m
+}
+
+// polarRingStarts returns the index of the first node of every ring, and the total number of nodes at the end
+func polarRingStarts(rings int) []int {
+	ringStarts := make([]int, rings+1)
+	ringStarts[1] = 1
+	for ring := 1; ring < rings; ring++ {
+		previous := ringStarts[ring] - ringStarts[ring-1]
+		// A ring is 2*pi*ring nodes around, so split each node of the previous ring until they are about 1 node wide
+		ratio := int(math.Round(2 * math.Pi * float64(ring) / float64(previous)))
+		if ratio < 1 {
+			ratio = 1
+		}
+		ringStarts[ring+1] = ringStarts[ring] + previous*ratio
+	}
+	return ringStarts
+}
+
+// ringSize returns the number of nodes in a ring
+func (m *maze) ringSize(ring int) int {
+	return m.ringStarts[ring+1] - m.ringStarts[ring]
+}
+
+// polarCoords returns (ring, col) from index
+func (m *maze) polarCoords(index int) (int, int) {
+	ring := sort.Search(len(m.ringStarts), func(i int) bool { return m.ringStarts[i] > index }) - 1
+	return ring, index - m.ringStarts[ring]
+}
+
+// polarSides returns the sides of the polar node at (ring, col), in the order given by POLAR_IN.
+func (m *maze) polarSides(ring int, col int) []int {
+	// The center has one side for each node of the first ring
+	if ring == 0 {
+		sides := []int{-1, -1, -1}
+		for i := 0; i < m.ringSize(1); i++ {
+			sides = append(sides, m.ringStarts[1]+i)
+		}
+		return sides
+	}
+
+	size := m.ringSize(ring)
+	in := 0
+	if ring > 1 {
+		in = m.ringStarts[ring-1] + col/(size/m.ringSize(ring-1))
+	}
+	sides := []int{
+		in,
+		m.ringStarts[ring] + (col+1)%size,
+		m.ringStarts[ring] + (col+size-1)%size,
+	}
+	// The outer ring still has a side facing out, which is always a wall
+	if ring == m.height-1 {
+		return append(sides, -1)
+	}
+	ratio := m.ringSize(ring+1) / size
+	for i := 0; i < ratio; i++ {
+		sides = append(sides, m.ringStarts[ring+1]+col*ratio+i)
+	}
+	return sides
+}
@@ -0,0 +1,84 @@
+package maze
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Portals connect two nodes anywhere in the maze with an edge, so moving into one end moves out of the other.
+// They are added before the maze is generated. Generators only add and remove walls between nodes that are next to each other,
+// so they leave portals in place.
+
+// ParsePortalPairs reads a list of portals from text like "12-340,55-600", where each pair is the index of both ends.
+func ParsePortalPairs(text string) ([][]int, error) {
+	pairs := make([][]int, 0)
+	if strings.TrimSpace(text) == "" {
+		return pairs, nil
+	}
+	for _, part := range strings.Split(text, ",") {
+		ends := strings.Split(strings.TrimSpace(part), "-")
+		if len(ends) != 2 {
+			return nil, mkErr("invalid portal " + part)
+		}
+		pair := make([]int, 2)
+		for i, end := range ends {
+			index, err := strconv.Atoi(strings.TrimSpace(end))
+			if err != nil {
+				return nil, mkErr("invalid portal " + part)
+			}
+			pair[i] = index
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// addPortal connects index1 and index2 with a portal.
+// Both ends must be in the maze, not masked, not next to each other, and not already the end of a portal.
+func (m *maze) addPortal(index1 int, index2 int) error {
+	for _, index := range []int{index1, index2} {
+		if index < 0 || index >= len(m.g.nodes) || m.isMasked(index) {
+			return mkErr("portal " + strconv.Itoa(index) + " is not in the maze")
+		}
+		if m.portalLabel(index) != 0 {
+			return mkErr("node " + strconv.Itoa(index) + " already has a portal")
+		}
+	}
+	if index1 == index2 {
+		return mkErr("portal can't lead to itself")
+	}
+	for _, n := range m.gridSides(index1) {
+		if n == index2 {
+			return mkErr("portal ends can't be next to each other")
+		}
+	}
+	m.portals = append(m.portals, []int{index1, index2})
+	m.g.addEdgeKind(index1, index2, EDGE_PORTAL)
+	return nil
+}
+
+// addRandomPortals adds count portals between random nodes.
+// It gives up if it can't find places for them, which only happens in very small mazes.
+func (m *maze) addRandomPortals(count int) error {
+	for attempts := 0; count > 0; attempts++ {
+		if attempts > 100 {
+			return mkErr("not enough room for portals")
+		}
+		if m.addPortal(m.randomNode(), m.randomNode()) == nil {
+			count--
+			attempts = 0
+		}
+	}
+	return nil
+}
+
+// portalLabel returns the number of the portal at index, starting at 1, or 0 if there isn't one.
+// Both ends of a portal have the same number.
+func (m *maze) portalLabel(index int) int {
+	for i, pair := range m.portals {
+		if pair[0] == index || pair[1] == index {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,195 @@
+package maze
+
+// Sides of a square node, in the same order as MNode.
+// Only mazes with more than one level have stairs to the levels above and below.
+const (
+	SQUARE_UP = iota
+	SQUARE_DOWN
+	SQUARE_RIGHT
+	SQUARE_LEFT
+	SQUARE_ABOVE
+	SQUARE_BELOW
+)
+
+// Sides of a hexagonal node, clockwise from the top right.
+// Hexagons are pointy-topped, and odd rows are shifted right by half a node.
+const (
+	HEX_NE = iota
+	HEX_E
+	HEX_SE
+	HEX_SW
+	HEX_W
+	HEX_NW
+)
+
+// Sides of a triangular node.
+// Nodes alternate between pointing up and pointing down, starting with up in the top left corner.
+// The base is the flat side across from the point, which is on the bottom of nodes pointing up and the top of nodes pointing down.
+const (
+	TRI_LEFT = iota
+	TRI_RIGHT
+	TRI_BASE
+)
+
+// initLevelMaze makes a maze of square nodes with levels stacked on top of each other.
+// Every level is like initMaze, and each node has stairs to the nodes in the same place on the levels above and below.
+func initLevelMaze(levels int, height int, width int) *maze {
+	if levels < 1 {
+		return nil
+	}
+	m := initMaze(height*levels, width)
+	if m == nil || height < 2 {
+		return nil
+	}
+	m.height = height
+	m.levels = levels
+	return m
+}
+
+// setWrap makes the edges of a maze of square nodes wrap around to the other side, depending on wrap (one of the WRAP_ selectors).
+// Nodes on the right edge are next to the nodes on the left edge of the same row when rows wrap, and the same goes for columns.
+func (m *maze) setWrap(wrap string) error {
+	switch wrap {
+	case WRAP_NONE, "":
+	case WRAP_HORIZONTAL:
+		m.wrapRows = true
+	case WRAP_VERTICAL:
+		m.wrapCols = true
+	case WRAP_BOTH:
+		m.wrapRows = true
+		m.wrapCols = true
+	default:
+		return mkErr("invalid wrap")
+	}
+	if wrap != WRAP_NONE && wrap != "" && m.shape != SHAPE_SQUARE {
+		return mkErr("only square nodes can wrap")
+	}
+	// A node can't be next to the same node on both sides
+	if (m.wrapRows && m.width < 3) || (m.wrapCols && m.height < 3) {
+		return mkErr("mazes that wrap must be at least 3 nodes across")
+	}
+	return nil
+}
+
+// initHexMaze makes a maze of hexagonal nodes, which fill left to right, then top to bottom like initMaze.
+func initHexMaze(height int, width int) *maze {
+	m := initMaze(height, width)
+	if m == nil {
+		return nil
+	}
+	m.shape = SHAPE_HEX
+	return m
+}
+
+// initTriangleMaze makes a maze of triangular nodes, which fill left to right, then top to bottom like initMaze.
+func initTriangleMaze(height int, width int) *maze {
+	m := initMaze(height, width)
+	if m == nil {
+		return nil
+	}
+	m.shape = SHAPE_TRIANGLE
+	return m
+}
+
+// TrianglePointsUp returns true if the triangular node at (row, col) points up
+func TrianglePointsUp(row int, col int) bool {
+	return (row+col)%2 == 0
+}
+
+// sides returns the index of the node across each side of the node at index, in the order of the maze's shape.
+// Sides on the edge of the maze, or next to a masked node, are -1.
+// Every side of a masked node is -1.
+// - SHAPE_SQUARE is SQUARE_UP through SQUARE_LEFT (the same as MNode), then SQUARE_ABOVE and SQUARE_BELOW if there are levels
+// - SHAPE_HEX is HEX_NE through HEX_NW
+// - SHAPE_TRIANGLE is TRI_LEFT through TRI_BASE
+// - SHAPE_POLAR is POLAR_IN through POLAR_OUT, with a side for every outward neighbor
+func (m *maze) sides(index int) []int {
+	sides := m.gridSides(index)
+	if m.masked != nil {
+		for i, n := range sides {
+			if n != -1 && (m.masked[index] || m.masked[n]) {
+				sides[i] = -1
+			}
+		}
+	}
+	return sides
+}
+
+// gridSides is sides without the mask.
+func (m *maze) gridSides(index int) []int {
+	row, col := getMazeCoords(m, index)
+	switch m.shape {
+	case SHAPE_POLAR:
+		return m.polarSides(row, col)
+	case SHAPE_TRIANGLE:
+		// The base of a node pointing up is shared with the node below it, which points down
+		base := row + 1
+		if !TrianglePointsUp(row, col) {
+			base = row - 1
+		}
+		return []int{
+			m.indexOrNone(row, col-1),
+			m.indexOrNone(row, col+1),
+			m.indexOrNone(base, col),
+		}
+	case SHAPE_HEX:
+		// Odd rows are shifted right, so their diagonal neighbors are one column further right
+		shift := row % 2
+		return []int{
+			m.indexOrNone(row-1, col+shift),
+			m.indexOrNone(row, col+1),
+			m.indexOrNone(row+1, col+shift),
+			m.indexOrNone(row+1, col+shift-1),
+			m.indexOrNone(row, col-1),
+			m.indexOrNone(row-1, col+shift-1),
+		}
+	default:
+		// Up and down stop at the edge of the level, unless the maze wraps
+		sides := []int{-1, -1, -1, -1}
+		if row%m.height > 0 {
+			sides[SQUARE_UP] = index - m.width
+		} else if m.wrapCols {
+			sides[SQUARE_UP] = index + (m.height-1)*m.width
+		}
+		if row%m.height < m.height-1 {
+			sides[SQUARE_DOWN] = index + m.width
+		} else if m.wrapCols {
+			sides[SQUARE_DOWN] = index - (m.height-1)*m.width
+		}
+		if col < m.width-1 {
+			sides[SQUARE_RIGHT] = index + 1
+		} else if m.wrapRows {
+			sides[SQUARE_RIGHT] = index - (m.width - 1)
+		}
+		if col > 0 {
+			sides[SQUARE_LEFT] = index - 1
+		} else if m.wrapRows {
+			sides[SQUARE_LEFT] = index + m.width - 1
+		}
+		if m.levels == 1 {
+			return sides
+		}
+		// Stairs move a whole level of rows
+		return append(sides, m.indexOrNone(row-m.height, col), m.indexOrNone(row+m.height, col))
+	}
+}
+
+// neighbors returns the index of every node that shares a side with the node at index.
+func (m *maze) neighbors(index int) []int {
+	sides := m.sides(index)
+	neighbors := make([]int, 0, len(sides))
+	for _, n := range sides {
+		if n != -1 {
+			neighbors = append(neighbors, n)
+		}
+	}
+	return neighbors
+}
+
+// indexOrNone returns the index of (row, col), or -1 if it is outside the maze
+func (m *maze) indexOrNone(row int, col int) int {
+	if !m.inMaze(row, col) {
+		return -1
+	}
+	return getMazeIndex(m, row, col)
+}
@@ -0,0 +1,87 @@
+package maze
+
+import "math/rand"
+
+// Kinds of terrain, which change the cost of moving onto a node.
+// Without terrain, every move costs 1.
+const (
+	TERRAIN_PLAIN = iota
+	TERRAIN_ROAD
+	TERRAIN_MUD
+	TERRAIN_WATER
+)
+
+// terrainCosts is the cost of moving onto a node of each kind of terrain, in the order given by TERRAIN_PLAIN.
+// Roads are the only terrain cheaper than plain nodes.
+var terrainCosts = []int{2, 1, 5, 10}
+
+// paintTerrain covers about percent of the nodes with regions of road, mud and water, and leaves the rest plain.
+// It must be called before any edges are added, because the weight of an edge is set when it is added.
+func (m *maze) paintTerrain(percent int) {
+	m.terrain = make([]int, len(m.g.nodes))
+	for _, n := range m.g.nodes {
+		n.cost = terrainCosts[TERRAIN_PLAIN]
+	}
+	toPaint := m.openNodes() * percent / 100
+	// Regions grow up to a tenth of the maze, so there are always a few of them
+	maxRegion := m.openNodes()/10 + 3
+	for tries := 0; toPaint > 0 && tries < 1000; tries++ {
+		terrain := TERRAIN_ROAD + rand.Intn(len(terrainCosts)-1)
+		index := m.randomNode()
+		if m.terrain[index] != TERRAIN_PLAIN {
+			continue
+		}
+		size := 1 + rand.Intn(maxRegion)
+		if size > toPaint {
+			size = toPaint
+		}
+		toPaint -= m.paintRegion(index, terrain, size)
+	}
+}
+
+// paintRegion grows a region of terrain out from the plain node at index, in random directions, until it has size nodes or runs out of plain nodes.
+// It returns the number of nodes painted.
+func (m *maze) paintRegion(index int, terrain int, size int) int {
+	frontier := []int{index}
+	painted := 0
+	for len(frontier) > 0 && painted < size {
+		i := rand.Intn(len(frontier))
+		next := frontier[i]
+		frontier = append(frontier[:i], frontier[i+1:]...)
+		if m.terrain[next] != TERRAIN_PLAIN {
+			continue
+		}
+		m.terrain[next] = terrain
+		m.g.nodes[next].cost = terrainCosts[terrain]
+		painted++
+		for _, n := range m.neighbors(next) {
+			if m.terrain[n] == TERRAIN_PLAIN {
+				frontier = append(frontier, n)
+			}
+		}
+	}
+	return painted
+}
+
+// terrainAt returns the TERRAIN_ of the node at index
+func (m *maze) terrainAt(index int) int {
+	if m.terrain == nil {
+		return TERRAIN_PLAIN
+	}
+	return m.terrain[index]
+}
+
+// pathCost returns the total weight of the edges along path, walking from its last node to its first like Result.Best.
+// The cost of a step is the weight of the edge it takes, which is the cost of the node it moves onto.
+func pathCost(g *graph, path []int) int {
+	cost := 0
+	for i := len(path) - 1; i > 0; i-- {
+		for _, adj := range g.nodes[path[i]].neighbors {
+			if adj.n.index == path[i-1] {
+				cost += adj.weight
+				break
+			}
+		}
+	}
+	return cost
+}
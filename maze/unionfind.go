@@ -0,0 +1,48 @@
+package maze
+
+// disjointSet is a union-find structure over the indexes 0 to size-1.
+// Every index starts in its own set.
+type disjointSet struct {
+	parents []int
+	ranks   []int
+}
+
+func makeDisjointSet(size int) *disjointSet {
+	var d disjointSet
+	d.parents = make([]int, size, size)
+	d.ranks = make([]int, size, size)
+	for i := range d.parents {
+		d.parents[i] = i
+	}
+	return &d
+}
+
+// find returns the representative index of the set containing i.
+// It halves the path to the representative as it goes so later calls are faster.
+func (d *disjointSet) find(i int) int {
+	for d.parents[i] != i {
+		d.parents[i] = d.parents[d.parents[i]]
+		i = d.parents[i]
+	}
+	return i
+}
+
+// union merges the sets containing i1 and i2.
+// It returns false if they were already in the same set.
+func (d *disjointSet) union(i1 int, i2 int) bool {
+	root1 := d.find(i1)
+	root2 := d.find(i2)
+	if root1 == root2 {
+		return false
+	}
+
+	// Attach the shorter tree under the taller one to keep the trees flat
+	if d.ranks[root1] < d.ranks[root2] {
+		root1, root2 = root2, root1
+	}
+	d.parents[root2] = root1
+	if d.ranks[root1] == d.ranks[root2] {
+		d.ranks[root1]++
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package maze
+
+// wallFollower walks through the maze from startIndex with one hand on the wall, like a person would, until it reaches the goal.
+// With leftHand, it always takes the leftmost way out of a node, and otherwise the rightmost.
+// It returns:
+// - a boolean which is true if the goal was reached
+// - a path slice with every node walked through in order, which goes back over the same nodes when it backs out of dead ends
+// - a solution slice with the walk's loops and dead ends cut out, starting with the goal and ending with the start like bfs
+// Walls are the same as in MNode, so it goes through tunnels, but not portals, and it only works on one level.
+// In a maze with loops, it can walk in circles forever without reaching the goal, which is returned as an error.
+func wallFollower(m *maze, startIndex int, leftHand bool) (exists bool, path *[][]int, solution *[]int, err error) {
+	walk := make([]int, 0)
+	if m.levels > 1 {
+		return false, &[][]int{walk}, &[]int{}, mkErr("wall followers only work on one level")
+	}
+	// Start with a hand on the first wall, if there is one
+	index := startIndex
+	order := m.clockwise(index)
+	entry := order[0]
+	for _, side := range order {
+		if next, _ := m.stepThrough(index, side); next == -1 {
+			entry = side
+			break
+		}
+	}
+
+	// The next step only depends on the node and the side it was entered from, so seeing both again means it is going in circles
+	seen := make(map[[2]int]bool)
+	for index != m.goal {
+		state := [2]int{index, entry}
+		if seen[state] {
+			return false, &[][]int{walk}, &[]int{}, mkErr("wall follower walked in circles without finding the goal")
+		}
+		seen[state] = true
+		walk = append(walk, index)
+
+		index, entry = m.followWall(index, entry, leftHand)
+		if index == -1 {
+			return false, &[][]int{walk}, &[]int{}, mkErr("wall follower is walled in")
+		}
+	}
+
+	best := removeLoops(append(walk, m.goal), len(m.g.nodes))
+	for i, j := 0, len(best)-1; i < j; i, j = i+1, j-1 {
+		best[i], best[j] = best[j], best[i]
+	}
+	return true, &[][]int{walk}, &best, nil
+}
+
+// followWall returns the node the wall follower walks to from index, which it entered from the side entry, and the side of the new node it enters from.
+// Going clockwise from the side it came in keeps its left hand on the wall, and going counterclockwise keeps its right hand on the wall.
+// If it is walled in, the node is -1.
+func (m *maze) followWall(index int, entry int, leftHand bool) (int, int) {
+	order := m.clockwise(index)
+	start := 0
+	for i, side := range order {
+		if side == entry {
+			start = i
+		}
+	}
+	// The side it came in is tried last, which turns it around at a dead end
+	for i := 1; i <= len(order); i++ {
+		side := order[(start+i)%len(order)]
+		if !leftHand {
+			side = order[(start-i+len(order))%len(order)]
+		}
+		if next, from := m.stepThrough(index, side); next != -1 {
+			for nextSide, n := range m.sides(next) {
+				if n == from {
+					return next, nextSide
+				}
+			}
+		}
+	}
+	return -1, -1
+}
+
+// stepThrough returns the node reached by walking out of side of the node at index, and the node it enters the new node from.
+// That is index for a passage, and the node crossed by a tunnel. The node reached is -1 if there is a wall.
+func (m *maze) stepThrough(index int, side int) (int, int) {
+	n := m.sides(index)[side]
+	if n != -1 && m.g.hasEdge(index, n) {
+		return n, index
+	}
+	if m.hasTunnel(index, side) {
+		return m.sides(n)[side], n
+	}
+	return -1, -1
+}
+
+// clockwise returns the sides of the node at index in clockwise order, as drawn on the webpage
+func (m *maze) clockwise(index int) []int {
+	row, col := getMazeCoords(m, index)
+	switch m.shape {
+	case SHAPE_HEX:
+		return []int{HEX_NE, HEX_E, HEX_SE, HEX_SW, HEX_W, HEX_NW}
+	case SHAPE_TRIANGLE:
+		// Nodes pointing down are upside down, which reverses the order of their sides
+		if TrianglePointsUp(row, col) {
+			return []int{TRI_LEFT, TRI_RIGHT, TRI_BASE}
+		}
+		return []int{TRI_BASE, TRI_RIGHT, TRI_LEFT}
+	case SHAPE_POLAR:
+		// The y axis points down the page, so going clockwise around a node goes against the angle on the inner ring and with it on the outer ring.
+		// The center has nothing but outward sides, which go clockwise in order.
+		sides := len(m.sides(index))
+		order := make([]int, 0, sides)
+		if row > 0 {
+			order = append(order, POLAR_IN, POLAR_CCW)
+		}
+		for side := POLAR_OUT; side < sides; side++ {
+			order = append(order, side)
+		}
+		if row > 0 {
+			order = append(order, POLAR_CW)
+		}
+		return order
+	}
+	return []int{SQUARE_UP, SQUARE_RIGHT, SQUARE_DOWN, SQUARE_LEFT}
+}
+
+// removeLoops returns walk without the parts that come back to a node it has already been to, which leaves the path it would take without wrong turns.
+// size is the number of nodes in the maze.
+func removeLoops(walk []int, size int) []int {
+	positions := make([]int, size)
+	for i := range positions {
+		positions[i] = -1
+	}
+	path := make([]int, 0, len(walk))
+	for _, index := range walk {
+		if positions[index] != -1 {
+			for _, removed := range path[positions[index]+1:] {
+				positions[removed] = -1
+			}
+			path = path[:positions[index]+1]
+			continue
+		}
+		positions[index] = len(path)
+		path = append(path, index)
+	}
+	return path
+}
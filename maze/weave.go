@@ -0,0 +1,64 @@
+package maze
+
+// Weave mazes have tunnels that go under a node, connecting the nodes on either side of it.
+// The node that is crossed keeps its own passage over the tunnel, which runs across it.
+// Tunnels are graph edges like any other, so solving algorithms go through them without knowing they are tunnels.
+
+// tunnelEnd returns the node that a tunnel from index could reach by going under the neighbor on side, or -1 if there can't be a tunnel there.
+// Tunnels only go straight under one node, whose passages must cross the tunnel: open on both sides across it, and walled on both sides along it.
+// The node at the end of the tunnel must not have been visited yet.
+func (m *maze) tunnelEnd(index int, side int, visited []bool) int {
+	if m.shape != SHAPE_SQUARE || side > SQUARE_LEFT {
+		return -1
+	}
+	under := m.sides(index)[side]
+	if under == -1 {
+		return -1
+	}
+	if _, crossed := m.crossings[under]; crossed {
+		return -1
+	}
+	underSides := m.sides(under)
+	end := underSides[side]
+	if end == -1 || end == index || visited[end] {
+		return -1
+	}
+
+	// Square sides come in opposite pairs, so side^1 is the side across from side
+	across := SQUARE_RIGHT
+	if side >= SQUARE_RIGHT {
+		across = SQUARE_UP
+	}
+	for _, s := range []int{across, across ^ 1} {
+		if underSides[s] == -1 || !m.g.hasEdge(under, underSides[s]) {
+			return -1
+		}
+	}
+	// Any other way out of the node, such as the end of another tunnel, would collide with the tunnel
+	if m.passages(under) != 2 {
+		return -1
+	}
+	return end
+}
+
+// addTunnel connects index to end with a tunnel under the neighbor on side, which must be checked with tunnelEnd first
+func (m *maze) addTunnel(index int, side int, end int) {
+	if m.crossings == nil {
+		m.crossings = make(map[int]bool)
+	}
+	m.crossings[m.sides(index)[side]] = side == SQUARE_UP || side == SQUARE_DOWN
+	m.g.addEdgeKind(index, end, EDGE_TUNNEL)
+}
+
+// hasTunnel returns true if there is a tunnel from index under the neighbor on side
+func (m *maze) hasTunnel(index int, side int) bool {
+	if m.crossings == nil || side > SQUARE_LEFT {
+		return false
+	}
+	under := m.sides(index)[side]
+	if _, crossed := m.crossings[under]; under == -1 || !crossed {
+		return false
+	}
+	end := m.sides(under)[side]
+	return end != -1 && m.g.edgeKind(index, end) == EDGE_TUNNEL
+}
@@ -1,8 +1,11 @@
 package mazesrv
 
-import "go-mazes/maze"
+import (
+	"go-mazes/maze"
+	"strconv"
+)
 
-const MAZEHTML = `
+var MAZEHTML = `
 <!DOCTYPE html>
 <html lang="en">
 <head>
@@ -18,7 +21,7 @@ const MAZEHTML = `
             border-style: none;
             background-color: white;
         }
-        #maze {
+        .maze-level {
             border-collapse: collapse;
             font-size: 4px;
         }
@@ -34,9 +37,93 @@ const MAZEHTML = `
         .b-l {
             border-left-style: solid;
         }
+        .t-road {
+            background-color: tan;
+        }
+        .t-mud {
+            background-color: sienna;
+        }
+        .t-water {
+            background-color: lightblue;
+        }
         .c-goal {
             background-color: yellow;
         }
+        .u-v {
+            border-top-style: dashed;
+            border-bottom-style: dashed;
+        }
+        .u-h {
+            border-right-style: dashed;
+            border-left-style: dashed;
+        }
+        .masked {
+            border-style: none;
+        }
+        .st-u {
+            box-shadow: inset 0 0 0 1px blue;
+        }
+        .st-d {
+            box-shadow: inset 0 0 0 1px green;
+        }
+        .st-u.st-d {
+            box-shadow: inset 0 0 0 1px purple;
+        }
+        .ow-u::before, .ow-d::before, .ow-r::after, .ow-l::after {
+            color: red;
+        }
+        .ow-u::before {
+            content: "\2191";
+        }
+        .ow-d::before {
+            content: "\2193";
+        }
+        .ow-u.ow-d::before {
+            content: "\2195";
+        }
+        .ow-r::after {
+            content: "\2192";
+        }
+        .ow-l::after {
+            content: "\2190";
+        }
+        .ow-r.ow-l::after {
+            content: "\2194";
+        }
+        #level-buttons {
+            display: flex;
+            justify-content: center;
+            gap: 10px;
+            margin: 10px;
+        }
+        .maze-svg {
+            width: 95vw;
+            max-height: 85vh;
+        }
+        .maze-svg .cell {
+            fill: white;
+        }
+        .maze-svg .t-road {
+            fill: tan;
+        }
+        .maze-svg .t-mud {
+            fill: sienna;
+        }
+        .maze-svg .t-water {
+            fill: lightblue;
+        }
+        .maze-svg .c-goal {
+            fill: yellow;
+        }
+        .maze-svg .walls {
+            stroke: black;
+            stroke-width: 1;
+            stroke-linecap: round;
+            fill: none;
+        }
+        .maze-svg .arrows {
+            fill: red;
+        }
 
         #buttons {
             display: flex;
@@ -46,7 +133,7 @@ const MAZEHTML = `
             gap: 10px;
             margin: 30px;
         }
-        #hint-best-path {
+        #hint-best-path, #carved, #cost {
             text-align: center;
             padding: 20px;
             font-size: 20px;
@@ -77,25 +164,30 @@ const MAZEHTML = `
         let tickSpeed = {{ .TickSpeed }} ;
         let stepsFull = {{ .MPath }} ;
         let bestSteps = {{ .MBestPath }} ;
+        let divisions = {{ .MDivisions }} ;
+        let portals = {{ .MPortals }} ;
         let repeats = {{ .PathRepeats }} ;
         let halt = false;
         let formData = {{ .FormData }} ;
+        // Square mazes are drawn in a table, other shapes are drawn in an svg
+        let svgMode = {{ if .MSvg }}true{{ else }}false{{ end }} ;
+        // Rows continue from one level to the next, so each level starts levelHeight rows after the last
+        let levelHeight = {{ .LevelHeight }} ;
 
-        let gen_dfs = "` + maze.GEN_DFS + `"
-        let gen_none = "` + maze.GEN_NONE + `"
-        let gen_rand = "` + maze.GEN_RAND + `"
-
-        let solve_bfs_multi = "` + maze.SOLVE_BFS_MULTI + `"
-        let solve_bfs_single = "` + maze.SOLVE_BFS_SINGLE + `"
-        let solve_dfs_multi = "` + maze.SOLVE_DFS_MULTI + `"
+        let divide_horizontal = ` + strconv.Itoa(maze.DIVIDE_HORIZONTAL) + `
 
         const timer = ms => new Promise(res => setTimeout(res, ms))
         window.addEventListener("load", async function () {
             document.getElementById("overlay").style.display = "none"
             initFormData()
+            initLevels()
+            markPortals()
             document.getElementById("maze").addEventListener("click", async function () {
                 halt = !halt;
             });
+            if (divisions.length > 0) {
+                await drawDivisions();
+            }
             if (stepsFull.length > 0) {
                 await drawAllPathsSimultaneously();
             }
@@ -105,35 +197,62 @@ const MAZEHTML = `
         });
 
         function initFormData() {
-            let formStart = document.getElementById("buttons").firstElementChild.nextElementSibling
-            let genStart = formStart.firstElementChild
-            switch (formData[0]) {
-                case gen_dfs:
-                    genStart.selected = true
-                    break
-                case gen_rand:
-                    genStart.nextElementSibling.selected = true
-                    break
-                default:
-                    genStart.nextElementSibling.nextElementSibling.selected = true
-                    break
+            // formData maps the id of each input to its value
+            for (const id in formData) {
+                let input = document.getElementById(id)
+                if (input.type === "checkbox") {
+                    input.checked = formData[id] === "true"
+                } else {
+                    input.value = formData[id]
+                }
             }
-            let solveStart = formStart.nextElementSibling.nextElementSibling.nextElementSibling.firstElementChild
-            switch (formData[1]) {
-                case solve_bfs_single:
-                    solveStart.selected = true
-                    break
-                case solve_bfs_multi:
-                    solveStart.nextElementSibling.selected = true
-                    break
-                case solve_dfs_multi:
-                    solveStart.nextElementSibling.nextElementSibling.selected = true
-                    break
+        }
+
+        // initLevels adds a button to show each level of the maze, if there is more than one
+        function initLevels() {
+            let levels = document.getElementsByClassName("maze-level")
+            if (levels.length < 2) {
+                return
             }
-            let numObj = formStart.nextElementSibling.nextElementSibling.nextElementSibling.nextElementSibling.nextElementSibling.nextElementSibling
-            for (let i = 2; i < formData.length; i++) {
-                numObj.value = formData[i]
-                numObj = numObj.nextElementSibling.nextElementSibling.nextElementSibling
+            let buttons = document.getElementById("level-buttons")
+            for (let i = 0; i < levels.length; i++) {
+                let button = document.createElement("button")
+                button.type = "button"
+                button.textContent = "Level " + (i + 1)
+                button.addEventListener("click", function () {
+                    showLevel(i)
+                })
+                buttons.appendChild(button)
+            }
+        }
+
+        // showLevel hides every level except one, without stopping the animation
+        function showLevel(level) {
+            let levels = document.getElementsByClassName("maze-level")
+            for (let i = 0; i < levels.length; i++) {
+                levels[i].style.display = i === level ? "" : "none"
+            }
+        }
+
+        // markPortals outlines both ends of each portal in the same color, and labels them with the portal's number
+        function markPortals() {
+            for (let i = 0; i < portals.length; i++) {
+                let color = getRandomColor()
+                let label = "Portal " + (i + 1)
+                for (let j = 0; j < portals[i].length; j++) {
+                    let node = getObjFromCoords(portals[i], j)
+                    if (svgMode) {
+                        node.style.stroke = color
+                        node.style.strokeWidth = "3"
+                        let title = document.createElementNS("http://www.w3.org/2000/svg", "title")
+                        title.textContent = label
+                        node.appendChild(title)
+                    } else {
+                        node.style.outline = "2px solid " + color
+                        node.style.outlineOffset = "-2px"
+                        node.title = label
+                    }
+                }
             }
         }
 
@@ -162,6 +281,51 @@ const MAZEHTML = `
             await drawMaze(bestSteps, "red", 1, 0, repeats);
         }
 
+        // drawDivisions animates the walls added by recursive division.
+        // The maze is rendered with every wall, so the divided walls are hidden first and added back in order.
+        async function drawDivisions() {
+            for (let i = 0; i < divisions.length; i++) {
+                setDivision(divisions[i], false)
+            }
+            let filled = 0
+            for (let i = 0; i < divisions.length; i++) {
+                filled += setDivision(divisions[i], true)
+                // Like drawMaze, only wait after every repeats squares
+                if (filled >= repeats) {
+                    filled = 0
+                    while (halt) {
+                        await timer(tickSpeed);
+                    }
+                    await timer(tickSpeed);
+                }
+            }
+        }
+
+        // setDivision adds or removes a wall of [orientation, row, col, length, gap] and returns the number of squares it covers.
+        // Horizontal walls are below the squares they cover, and vertical walls are to the right.
+        function setDivision(division, add) {
+            let [orientation, row, col, length, gap] = division
+            for (let i = 0; i < length; i++) {
+                if (i === gap) {
+                    continue
+                }
+                let first, second
+                if (orientation === divide_horizontal) {
+                    first = getCell(row, col + i)
+                    second = getCell(row + 1, col + i)
+                    first.classList.toggle("b-b", add)
+                    second.classList.toggle("b-t", add)
+                } else {
+                    first = getCell(row + i, col)
+                    second = getCell(row + i, col + 1)
+                    first.classList.toggle("b-r", add)
+                    second.classList.toggle("b-l", add)
+                }
+            }
+            // A gap of -1 means the wall has no gap
+            return gap === -1 ? length : length - 1
+        }
+
         // drawMaze goes through every index in steps and adds a class based on color.
         // startOffset is the index of steps that drawMaze starts on (to traverse full array, 0).
         // endOffset is how many before the end of steps that drawMaze will stop (to traverse full array, 0).
@@ -173,6 +337,7 @@ const MAZEHTML = `
                     if(i < steps.length-endOffset) {
                         let goalObj = getObjFromCoords(steps, i)
                         goalObj.style.backgroundColor = color;
+                        goalObj.style.fill = color;
                         i++;
                     }
                 }
@@ -184,11 +349,18 @@ const MAZEHTML = `
             }
         }
 
+        // Steps are [row, col] in a table, or the index of the polygon in an svg
         function getObjFromCoords(steps, index){
-            let row = steps[index][0];
-            let col = steps[index][1];
+            if (svgMode) {
+                return document.getElementById("c" + steps[index]);
+            }
+            return getCell(steps[index][0], steps[index][1]);
+        }
 
-            let maze = document.getElementById("maze");
+        function getCell(row, col){
+            let level = Math.floor(row / levelHeight);
+            row -= level * levelHeight;
+            let maze = document.getElementById("level-" + level);
             // Pick the first row
             let r = maze.firstElementChild.firstElementChild;
             // Step to the correct row
@@ -231,6 +403,16 @@ const MAZEHTML = `
             <label for="generateAlgorithm">Generation algorithm:</label>
             <select name="generateAlgorithm" id="generateAlgorithm">
                 <option value="` + maze.GEN_DFS + `" selected>DFS</option>
+                <option value="` + maze.GEN_HUNT_KILL + `">Hunt-and-Kill</option>
+                <option value="` + maze.GEN_PRIM + `">Prim's</option>
+                <option value="` + maze.GEN_KRUSKAL + `">Kruskal's</option>
+                <option value="` + maze.GEN_WILSON + `">Wilson's</option>
+                <option value="` + maze.GEN_ALDOUS_BRODER + `">Aldous-Broder</option>
+                <option value="` + maze.GEN_ELLER + `">Eller's</option>
+                <option value="` + maze.GEN_DIVISION + `">Recursive Division</option>
+                <option value="` + maze.GEN_BINARY_TREE + `">Binary Tree</option>
+                <option value="` + maze.GEN_SIDEWINDER + `">Sidewinder</option>
+                <option value="` + maze.GEN_GROWING_TREE + `">Growing Tree</option>
                 <option value="` + maze.GEN_RAND + `">Random</option>
                 <option value="` + maze.GEN_NONE + `">None</option>
             </select>
@@ -240,6 +422,38 @@ const MAZEHTML = `
                 <option value="` + maze.SOLVE_BFS_SINGLE + `" selected>BFS</option>
                 <option value="` + maze.SOLVE_BFS_MULTI + `">BFS Multithreaded</option>
                 <option value="` + maze.SOLVE_DFS_MULTI + `">DFS Multithreaded</option>
+                <option value="` + maze.SOLVE_BFS_BIDIRECTIONAL + `">BFS Bidirectional</option>
+                <option value="` + maze.SOLVE_ASTAR + `">A*</option>
+                <option value="` + maze.SOLVE_DIJKSTRA + `">Dijkstra</option>
+                <option value="` + maze.SOLVE_WALL_FOLLOWER_LEFT + `">Wall Follower (Left Hand)</option>
+                <option value="` + maze.SOLVE_WALL_FOLLOWER_RIGHT + `">Wall Follower (Right Hand)</option>
+                <option value="` + maze.SOLVE_DEAD_END_FILL + `">Dead End Filling</option>
+            </select>
+            <br>
+            <label for="heuristic">Heuristic (for A*):</label>
+            <select name="heuristic" id="heuristic">
+                <option value="` + maze.HEURISTIC_MANHATTAN + `" selected>Manhattan</option>
+                <option value="` + maze.HEURISTIC_EUCLIDEAN + `">Euclidean</option>
+                <option value="` + maze.HEURISTIC_ZERO + `">Zero (Dijkstra)</option>
+            </select>
+            <br>
+            <label for="shape">Shape:</label>
+            <select name="shape" id="shape">
+                <option value="` + maze.SHAPE_SQUARE + `" selected>Square</option>
+                <option value="` + maze.SHAPE_TRIANGLE + `">Triangle</option>
+                <option value="` + maze.SHAPE_HEX + `">Hexagon</option>
+                <option value="` + maze.SHAPE_POLAR + `">Circle</option>
+            </select>
+            <br>
+            <label for="levels">Levels (for square mazes):</label>
+            <input type="number" id="levels" name="levels" min="1" max="10" value="1">
+            <br>
+            <label for="wrap">Wrap around edges (for square mazes):</label>
+            <select name="wrap" id="wrap">
+                <option value="` + maze.WRAP_NONE + `" selected>None</option>
+                <option value="` + maze.WRAP_HORIZONTAL + `">Left and right</option>
+                <option value="` + maze.WRAP_VERTICAL + `">Top and bottom</option>
+                <option value="` + maze.WRAP_BOTH + `">Both</option>
             </select>
             <br>
             <label for="width">Width:</label>
@@ -257,18 +471,61 @@ const MAZEHTML = `
             <label for="density">Density (for randomly generated mazes): </label>
             <input type="number" id="density" name="density" min="1" max="100" value="15">
             <br>
+            <label for="bias">Bias (for binary tree and sidewinder mazes): </label>
+            <select name="bias" id="bias">
+                <option value="` + maze.BIAS_NE + `" selected>Northeast</option>
+                <option value="` + maze.BIAS_NW + `">Northwest</option>
+                <option value="` + maze.BIAS_SE + `">Southeast</option>
+                <option value="` + maze.BIAS_SW + `">Southwest</option>
+            </select>
+            <br>
+            <label for="policy">Policy (for growing tree mazes, like newest:75,random:25): </label>
+            <input type="text" id="policy" name="policy" value="` + maze.POLICY_NEWEST + `">
+            <br>
+            <label for="weave">Percent chance of tunneling under a passage (for DFS mazes): </label>
+            <input type="number" id="weave" name="weave" min="0" max="100" value="0">
+            <br>
+            <label for="startIndex">Start index (counting left to right, then top to bottom): </label>
+            <input type="number" id="startIndex" name="startIndex" min="0" value="0">
+            <br>
+            <label for="mask">Mask (X or # leaves a node out of the maze, and the maze is the size of the mask): </label>
+            <textarea id="mask" name="mask" rows="4" cols="30"></textarea>
+            <br>
+            <label for="portals">Number of random portals: </label>
+            <input type="number" id="portals" name="portals" min="0" max="50" value="0">
+            <br>
+            <label for="portalPairs">More portals (pairs of indexes, like 12-340,55-600): </label>
+            <input type="text" id="portalPairs" name="portalPairs" value="">
+            <br>
+            <label for="oneWay">Percent of passages that only go one way: </label>
+            <input type="number" id="oneWay" name="oneWay" min="0" max="100" value="0">
+            <br>
+            <label for="terrain">Percent of nodes covered by road, mud and water: </label>
+            <input type="number" id="terrain" name="terrain" min="0" max="100" value="0">
+            <br>
+            <label for="braid">Percent of dead ends to remove: </label>
+            <input type="number" id="braid" name="braid" min="0" max="100" value="0">
+            <br>
+            <label for="solvable">Make sure the goal can be reached: </label>
+            <input type="checkbox" id="solvable" name="solvable">
+            <br>
             <input type="submit" value="Submit">
         </form>
     </div>
+    <div id="level-buttons"></div>
     <div id="container">
-        <table id="maze">
-            {{ range .MStyles }}<tr>
+        {{ if .MSvg }}{{ .MSvg }}{{ else }}<div id="maze">{{ range $level, $styles := .MStyles }}
+        <table class="maze-level" id="level-{{ $level }}"{{ if $level }} style="display: none"{{ end }}>
+            {{ range $styles }}<tr>
                 {{ range . }}<th class="{{.}}">     </th>
                 {{ end }}
             </tr>
             {{ end }}
-        </table>
+        </table>{{ end }}
+        </div>{{ end }}
     </div>
+    {{ if .Carved }}<h4 id="carved">Removed {{ .Carved }} walls to connect the start to the goal.</h4>{{ end }}
+    {{ if .Cost }}<h4 id="cost">The solution costs {{ .Cost }} to walk.</h4>{{ end }}
     <h4 style="display: none" id="hint-best-path">Click on the maze to draw the solution!</h4>
 </body>
 </html>
@@ -18,10 +18,46 @@ type MazeRequest struct {
 	GenerateAlg string
 	SolveAlg    string
 	StartIndex  uint64
+	// Bias is one of the maze.BIAS_ selectors, used by biased generators
+	Bias string
+	// Policy picks the next node for the growing tree generator, such as "newest:75,random:25"
+	Policy string
+	// Braid is the percent of dead ends removed after generation, from 0 to 100
+	Braid uint32
+	// Solvable connects the start to the goal if the generator cut it off
+	Solvable bool
+	// Shape is one of the maze.SHAPE_ selectors
+	Shape string
+	// Levels is the number of levels of square nodes, connected by stairs
+	Levels uint32
+	// Wrap is one of the maze.WRAP_ selectors, which makes the edges of a square maze wrap around
+	Wrap string
+	// Weave is the percent chance of tunneling under a passage, used by the DFS generator
+	Weave uint32
+	// Mask is ASCII art of the nodes to leave out of the maze, where 'X' or '#' is left out (see maze.ParseMask).
+	// MaskPNG is the same as a black and white PNG image, where black is left out.
+	// If either is set, the maze is the size of the mask instead of Width and Height.
+	Mask    string
+	MaskPNG []byte
+	// Portals is the number of portals between random nodes.
+	// PortalPairs lists more portals by the index of both ends, like "12-340,55-600".
+	Portals     uint32
+	PortalPairs string
+	// OneWay is the percent of passages that can only be passed in one direction.
+	// The path from the start to the goal is never blocked by them.
+	OneWay uint32
+	// Terrain is the percent of nodes covered by road, mud and water, which cost more or less to move onto.
+	Terrain uint32
+	// Heuristic is one of the maze.HEURISTIC_ selectors, used by the A* solver
+	Heuristic string
 }
 
 type MazeResponse struct {
 	Webpage string
+	// Carved is the number of walls removed to make the maze solvable
+	Carved uint32
+	// Cost is the cost of walking the solution, or 0 if there is no terrain and the solver ignores costs, or the solver is SOLVE_DEAD_END_FILL
+	Cost uint32
 }
 
 func mkErr(message string) error {
@@ -34,22 +70,39 @@ func GetMaze(req *MazeRequest, rep *MazeResponse) error {
 	}
 
 	in := MazeInputs{
-		width:      int(req.Width),
-		height:     int(req.Height),
-		tickSpeed:  int(req.TickSpeed),
-		repeats:    int(req.Repeats),
-		density:    int(req.Density),
-		solveAlg:   req.SolveAlg,
-		genAlg:     req.GenerateAlg,
-		startIndex: int(req.StartIndex),
+		width:       int(req.Width),
+		height:      int(req.Height),
+		tickSpeed:   int(req.TickSpeed),
+		repeats:     int(req.Repeats),
+		density:     int(req.Density),
+		solveAlg:    req.SolveAlg,
+		genAlg:      req.GenerateAlg,
+		startIndex:  int(req.StartIndex),
+		bias:        req.Bias,
+		policy:      req.Policy,
+		braid:       int(req.Braid),
+		solvable:    req.Solvable,
+		shape:       req.Shape,
+		levels:      int(req.Levels),
+		wrap:        req.Wrap,
+		weave:       int(req.Weave),
+		mask:        req.Mask,
+		maskPNG:     req.MaskPNG,
+		portals:     int(req.Portals),
+		portalPairs: req.PortalPairs,
+		oneWay:      int(req.OneWay),
+		terrain:     int(req.Terrain),
+		heuristic:   req.Heuristic,
 	}
 
 	buf := new(bytes.Buffer)
-	err := makeMaze(&in, buf)
+	tplData, err := makeMaze(&in, buf)
 	if err != nil {
 		return err
 	}
 
 	rep.Webpage = buf.String()
+	rep.Carved = uint32(tplData.Carved)
+	rep.Cost = uint32(tplData.Cost)
 	return nil
 }
@@ -1,9 +1,12 @@
 package mazesrv_test
 
 import (
+	"bytes"
 	"github.com/stretchr/testify/assert"
 	"go-mazes/maze"
 	ms "go-mazes/mazesrv"
+	"runtime"
+	"strings"
 	"testing"
 )
 
@@ -21,3 +24,289 @@ func TestMaze(t *testing.T) {
 	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
 	print("Maze Output: %v", res.Webpage)
 }
+
+func TestMazeOneCore(t *testing.T) {
+	// Multithreaded BFS shares the core with the thread manager that waits for it
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	arg := ms.MazeRequest{
+		Height:      100,
+		Width:       100,
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_MULTI,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+}
+
+func TestSolvableMaze(t *testing.T) {
+	// Random walls at this density almost always cut off the goal
+	arg := ms.MazeRequest{
+		Height:      50,
+		Width:       50,
+		Density:     60,
+		GenerateAlg: maze.GEN_RAND,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		Solvable:    true,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Greater(t, res.Carved, uint32(0))
+	assert.Contains(t, res.Webpage, "id=\"carved\"")
+}
+
+func TestStreamMaze(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := ms.StreamMaze(10, 3000, buf)
+	assert.Nil(t, err, "Maze stream failed with err: %v", err)
+	// One line for the top wall and two lines for each row
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	assert.Len(t, lines, 1+2*3000)
+	for _, line := range lines {
+		assert.Len(t, line, 1+3*10)
+	}
+}
+
+func TestHexMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_PRIM,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		Shape:       maze.SHAPE_HEX,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	// Hex mazes are drawn with one polygon per node instead of a table
+	assert.Equal(t, 20*30, strings.Count(res.Webpage, "<polygon"))
+	assert.NotContains(t, res.Webpage, "<table")
+
+	// Generators that only work on square nodes are swapped for DFS instead of failing
+	arg.GenerateAlg = maze.GEN_ELLER
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Equal(t, 20*30, strings.Count(res.Webpage, "<polygon"))
+	assert.Contains(t, res.Webpage, "\"generateAlgorithm\":\""+maze.GEN_DFS+"\"")
+
+	// Only square nodes wrap, so the wrap is dropped
+	arg.Wrap = maze.WRAP_BOTH
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Contains(t, res.Webpage, "\"wrap\":\""+maze.WRAP_NONE+"\"")
+}
+
+func TestPolarMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       20,
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		Shape:       maze.SHAPE_POLAR,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	// The center is a circle, and every ring around it is made of arcs
+	assert.Equal(t, 1, strings.Count(res.Webpage, "<circle"))
+	assert.Contains(t, res.Webpage, " A")
+
+	// The start is checked against the number of nodes in the rings, not the width
+	arg.Width, arg.Height, arg.StartIndex = 200, 3, 100
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Equal(t, 1, strings.Count(res.Webpage, "<circle"))
+}
+
+func TestLevelMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		Levels:      3,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	// Each level is its own table, and only the first is shown at the start
+	assert.Equal(t, 3, strings.Count(res.Webpage, "class=\"maze-level\""))
+	assert.Contains(t, res.Webpage, "id=\"level-0\">")
+	assert.Contains(t, res.Webpage, "id=\"level-2\" style=\"display: none\">")
+
+	// The start can be on any level, and the solution ends there
+	arg.StartIndex = 2*20*30 + 5
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Contains(t, res.Webpage, "[40, 5]] ;")
+
+	// Only square nodes have levels, so other shapes are drawn flat
+	arg.Shape = maze.SHAPE_HEX
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Equal(t, 20*30, strings.Count(res.Webpage, "<polygon"))
+}
+
+func TestMaskedMaze(t *testing.T) {
+	// The first node is masked, so the start moves to the next one
+	arg := ms.MazeRequest{
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		Mask:        "X.....\n......\n..XX..\n......",
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Equal(t, 3, strings.Count(res.Webpage, "class=\"masked\""))
+	assert.Equal(t, 4, strings.Count(res.Webpage, "<tr>"))
+
+	// Polar mazes and generators that need every node can't have a mask, so they fall back to square nodes and DFS
+	arg.Shape, arg.GenerateAlg = maze.SHAPE_POLAR, maze.GEN_ELLER
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Equal(t, 3, strings.Count(res.Webpage, "class=\"masked\""))
+
+	arg.Mask = "XXXX\n....\nXXXX\n...."
+	err = ms.GetMaze(&arg, &res)
+	assert.NotNil(t, err, "Masks that split the maze should fail")
+}
+
+func TestPortalMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		Portals:     2,
+		PortalPairs: "0-599",
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Contains(t, res.Webpage, "let portals = [[[0, 0], [19, 29]], ")
+
+	arg.PortalPairs = "0-1"
+	err = ms.GetMaze(&arg, &res)
+	assert.NotNil(t, err, "Portals between nodes next to each other should fail")
+}
+
+func TestOneWayMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      10,
+		Width:       10,
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		OneWay:      100,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Regexp(t, "ow-[udrl]", res.Webpage)
+
+	arg.Shape = maze.SHAPE_HEX
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Regexp(t, `<path class="arrows" d="M[0-9]`, res.Webpage)
+}
+
+func TestTerrainMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      10,
+		Width:       10,
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_SINGLE,
+		Terrain:     50,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Regexp(t, `class="t-(road|mud|water)`, res.Webpage)
+	assert.NotZero(t, res.Cost)
+	assert.Contains(t, res.Webpage, `<h4 id="cost">`)
+
+	arg.Terrain = 0
+	err = ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Zero(t, res.Cost)
+	assert.NotContains(t, res.Webpage, `class="t-`)
+}
+
+func TestAStarMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_NONE,
+		SolveAlg:    maze.SOLVE_ASTAR,
+		Heuristic:   maze.HEURISTIC_EUCLIDEAN,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Contains(t, res.Webpage, `"heuristic":"HEURISTIC_EUCLIDEAN"`)
+	assert.Contains(t, res.Webpage, "let bestSteps = [[19, 29], ")
+}
+
+func TestDijkstraMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_NONE,
+		SolveAlg:    maze.SOLVE_DIJKSTRA,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	// Every step costs 1 without terrain
+	assert.Equal(t, uint32(30+20-2), res.Cost)
+	assert.Contains(t, res.Webpage, `<h4 id="cost">The solution costs 48 to walk.</h4>`)
+}
+
+func TestBidirectionalMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_DFS,
+		SolveAlg:    maze.SOLVE_BFS_BIDIRECTIONAL,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	// The search from the start and the search from the goal are drawn as separate paths
+	assert.Regexp(t, `let stepsFull = \[\[\[0, 0\], .*\]\], \[\[`, res.Webpage)
+}
+
+func TestWallFollowerMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_KRUSKAL,
+		SolveAlg:    maze.SOLVE_WALL_FOLLOWER_LEFT,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	assert.Contains(t, res.Webpage, "let bestSteps = [[19, 29], ")
+
+	// Following a wall goes in circles around the open middle of the maze
+	arg.GenerateAlg = maze.GEN_NONE
+	arg.SolveAlg = maze.SOLVE_WALL_FOLLOWER_RIGHT
+	arg.StartIndex = 100
+	err = ms.GetMaze(&arg, &res)
+	assert.NotNil(t, err, "Wall follower should fail without walls to follow")
+}
+
+func TestDeadEndFillMaze(t *testing.T) {
+	arg := ms.MazeRequest{
+		Height:      20,
+		Width:       30,
+		GenerateAlg: maze.GEN_PRIM,
+		SolveAlg:    maze.SOLVE_DEAD_END_FILL,
+	}
+	res := ms.MazeResponse{}
+	err := ms.GetMaze(&arg, &res)
+	assert.Nil(t, err, "Maze RPC call failed with arg: %v and err: %v", arg, err)
+	// Every dead end is filled as its own path, and the start is left over at the end of the solution
+	assert.Regexp(t, `let stepsFull = \[\[\[\d+, \d+\].*\]\], \[\[`, res.Webpage)
+	assert.Regexp(t, `let bestSteps = \[\[19, 29\], .*\[0, 0\]\] ;`, res.Webpage)
+}
@@ -0,0 +1,77 @@
+package mazesrv
+
+import (
+	"bufio"
+	"fmt"
+	"go-mazes/maze"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// StreamMaze writes a width by height maze generated with Eller's algorithm to wr as ASCII art.
+// Rows are written as they are generated, so height can be much larger than what fits in memory.
+func StreamMaze(width int, height int, wr io.Writer) error {
+	buf := bufio.NewWriter(wr)
+	// The top of the first row is always a wall
+	top := "+"
+	for col := 0; col < width; col++ {
+		top += "--+"
+	}
+	_, err := buf.WriteString(top + "\n")
+	if err != nil {
+		return err
+	}
+
+	err = maze.StreamEllerMaze(width, height, func(row []maze.MNode) error {
+		_, err := buf.WriteString(rowToASCII(row))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return buf.Flush()
+}
+
+// rowToASCII draws the inside and bottom of a row of nodes, two characters wide each.
+// The top of the row is drawn by the bottom of the row before it.
+func rowToASCII(row []maze.MNode) string {
+	inside := "|"
+	bottom := "+"
+	for _, node := range row {
+		if node.Val == maze.NODE_GOAL {
+			inside += "()"
+		} else {
+			inside += "  "
+		}
+		if node.Right {
+			inside += "|"
+		} else {
+			inside += " "
+		}
+		if node.Down {
+			bottom += "--+"
+		} else {
+			bottom += "  +"
+		}
+	}
+	return inside + "\n" + bottom + "\n"
+}
+
+// MakeStreamResponse streams an ASCII maze of any height as a plain text http response
+func MakeStreamResponse(wr http.ResponseWriter, rd *http.Request) {
+	w, err := strconv.Atoi(rd.URL.Query().Get("width"))
+	if err != nil || w < 1 || w > 1000 {
+		w = 40
+	}
+	h, err := strconv.Atoi(rd.URL.Query().Get("height"))
+	if err != nil || h < 1 {
+		h = 40
+	}
+
+	wr.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	err = StreamMaze(w, h, wr)
+	if err != nil {
+		fmt.Printf("Maze stream error: %v\n", err)
+	}
+}
@@ -0,0 +1,278 @@
+package mazesrv
+
+import (
+	"go-mazes/maze"
+	"html/template"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Radius of a hexagon in svg units.
+// The svg is scaled to fit the page, so this only matters relative to the width of the walls.
+const hexRadius = 10.0
+
+// cellsToSvg draws a maze of non-square nodes.
+// Every node is a polygon with the id "c<index>" so the webpage can fill it in, and the walls are drawn on top as one path.
+func cellsToSvg(shape string, width int, height int, cells []maze.MCell) template.HTML {
+	switch shape {
+	case maze.SHAPE_TRIANGLE:
+		return triangleCellsToSvg(width, height, cells)
+	case maze.SHAPE_HEX:
+		return hexCellsToSvg(width, height, cells)
+	case maze.SHAPE_POLAR:
+		return polarCellsToSvg(height, cells)
+	}
+	return ""
+}
+
+// hexCorners returns the 6 corners of the hexagon at (row, col), clockwise from the top.
+// Side i of the hexagon (see maze.HEX_NE) runs from corner i to corner i+1.
+func hexCorners(row int, col int) [][]float64 {
+	hexWidth := math.Sqrt(3) * hexRadius
+	// Odd rows are shifted right by half a hexagon
+	centerX := hexWidth*(float64(col)+0.5*float64(row%2)) + hexWidth/2
+	centerY := 1.5*hexRadius*float64(row) + hexRadius
+
+	corners := make([][]float64, 6)
+	for i := range corners {
+		angle := math.Pi / 180 * float64(60*i-90)
+		corners[i] = []float64{centerX + hexRadius*math.Cos(angle), centerY + hexRadius*math.Sin(angle)}
+	}
+	return corners
+}
+
+func hexCellsToSvg(width int, height int, cells []maze.MCell) template.HTML {
+	var polygons strings.Builder
+	var walls strings.Builder
+	var arrows strings.Builder
+	for index, cell := range cells {
+		// Masked nodes are left blank
+		if cell.Masked {
+			continue
+		}
+		corners := hexCorners(cell.Row, cell.Col)
+		for side, oneWay := range cell.OneWay {
+			if oneWay == maze.ONEWAY_OUT {
+				arrows.WriteString(svgArrow(midpoint(corners...), midpoint(corners[side], corners[(side+1)%6])))
+			}
+		}
+
+		polygons.WriteString(`<polygon id="c` + strconv.Itoa(index) + `" class="` + cellClass(cell) + `" points="`)
+		for i, corner := range corners {
+			if i > 0 {
+				polygons.WriteString(" ")
+			}
+			polygons.WriteString(svgPoint(corner))
+		}
+		polygons.WriteString(`"/>` + "\n")
+
+		for side, wall := range cell.Walls {
+			// Walls shared with a neighbor are drawn by the neighbor on its east sides, so they are only drawn once
+			if !wall || (side >= maze.HEX_SW && cell.Neighbors[side] != -1) {
+				continue
+			}
+			walls.WriteString("M" + svgPoint(corners[side]) + " L" + svgPoint(corners[(side+1)%6]) + " ")
+		}
+	}
+
+	hexWidth := math.Sqrt(3) * hexRadius
+	viewWidth := hexWidth*(float64(width)+0.5) + 2
+	viewHeight := 1.5*hexRadius*float64(height-1) + 2*hexRadius + 2
+	return svg(viewWidth, viewHeight, polygons.String(), walls.String(), arrows.String())
+}
+
+// Length of a side of a triangle in svg units
+const triangleSide = 10.0
+
+// triangleCorners returns the corners of the triangle at (row, col), in the order given by maze.TRI_LEFT.
+// Side i of the triangle runs from corner i to corner i+1.
+func triangleCorners(row int, col int) [][]float64 {
+	triangleHeight := math.Sqrt(3) / 2 * triangleSide
+	left := triangleSide / 2 * float64(col)
+	top := triangleHeight * float64(row)
+	if maze.TrianglePointsUp(row, col) {
+		// Bottom left, top point, bottom right
+		return [][]float64{{left, top + triangleHeight}, {left + triangleSide/2, top}, {left + triangleSide, top + triangleHeight}}
+	}
+	// Top left, bottom point, top right
+	return [][]float64{{left, top}, {left + triangleSide/2, top + triangleHeight}, {left + triangleSide, top}}
+}
+
+func triangleCellsToSvg(width int, height int, cells []maze.MCell) template.HTML {
+	var shapes strings.Builder
+	var walls strings.Builder
+	var arrows strings.Builder
+	for index, cell := range cells {
+		if cell.Masked {
+			continue
+		}
+		corners := triangleCorners(cell.Row, cell.Col)
+		for side, oneWay := range cell.OneWay {
+			if oneWay == maze.ONEWAY_OUT {
+				arrows.WriteString(svgArrow(midpoint(corners...), midpoint(corners[side], corners[(side+1)%3])))
+			}
+		}
+
+		shapes.WriteString(`<polygon id="c` + strconv.Itoa(index) + `" class="` + cellClass(cell) + `" points="` +
+			svgPoint(corners[0]) + " " + svgPoint(corners[1]) + " " + svgPoint(corners[2]) + `"/>` + "\n")
+
+		for side, wall := range cell.Walls {
+			// Walls shared with a neighbor are drawn by the neighbor to the left or above, so they are only drawn once
+			shared := false
+			switch side {
+			case maze.TRI_LEFT:
+				shared = cell.Neighbors[side] != -1
+			case maze.TRI_BASE:
+				shared = !maze.TrianglePointsUp(cell.Row, cell.Col) && cell.Neighbors[side] != -1
+			}
+			if wall && !shared {
+				walls.WriteString("M" + svgPoint(corners[side]) + " L" + svgPoint(corners[(side+1)%3]) + " ")
+			}
+		}
+	}
+
+	triangleHeight := math.Sqrt(3) / 2 * triangleSide
+	return svg(triangleSide/2*float64(width+1)+2, triangleHeight*float64(height)+2, shapes.String(), walls.String(), arrows.String())
+}
+
+// Thickness of a ring in svg units
+const ringHeight = 10.0
+
+// polarPoint returns the point at a distance from the center of a polar maze, at an angle clockwise from the right.
+func polarPoint(center float64, distance float64, angle float64) []float64 {
+	return []float64{center + distance*math.Cos(angle), center + distance*math.Sin(angle)}
+}
+
+// polarArc returns a path command for an arc around the center, from the current point to the point at angle.
+func polarArc(center float64, distance float64, angle float64, clockwise bool) string {
+	sweep := " 0 "
+	if clockwise {
+		sweep = " 1 "
+	}
+	r := svgNumber(distance)
+	return " A" + r + "," + r + " 0 0" + sweep + svgPoint(polarPoint(center, distance, angle))
+}
+
+func polarCellsToSvg(rings int, cells []maze.MCell) template.HTML {
+	ringSizes := make([]int, rings)
+	for _, cell := range cells {
+		ringSizes[cell.Row]++
+	}
+	center := ringHeight * float64(rings)
+
+	var shapes strings.Builder
+	var walls strings.Builder
+	var arrows strings.Builder
+	for index, cell := range cells {
+		// The center is a circle with no walls of its own
+		if cell.Row == 0 {
+			shapes.WriteString(`<circle id="c` + strconv.Itoa(index) + `" class="` + cellClass(cell) + `" cx="` +
+				svgNumber(center) + `" cy="` + svgNumber(center) + `" r="` + svgNumber(ringHeight) + `"/>` + "\n")
+			for side := maze.POLAR_OUT; side < len(cell.OneWay); side++ {
+				if cell.OneWay[side] == maze.ONEWAY_OUT {
+					angle := 2 * math.Pi * (float64(side-maze.POLAR_OUT) + 0.5) / float64(ringSizes[1])
+					arrows.WriteString(svgArrow([]float64{center, center}, polarPoint(center, ringHeight, angle)))
+				}
+			}
+			continue
+		}
+
+		inner := ringHeight * float64(cell.Row)
+		outer := inner + ringHeight
+		first := 2 * math.Pi * float64(cell.Col) / float64(ringSizes[cell.Row])
+		second := 2 * math.Pi * float64(cell.Col+1) / float64(ringSizes[cell.Row])
+
+		// Arrows point from the middle of the node to the middle of each side it can only be left through.
+		// The outward sides split the outer arc evenly.
+		cellCenter := polarPoint(center, inner+ringHeight/2, (first+second)/2)
+		for side, oneWay := range cell.OneWay {
+			if oneWay != maze.ONEWAY_OUT {
+				continue
+			}
+			var sideMiddle []float64
+			switch side {
+			case maze.POLAR_IN:
+				sideMiddle = polarPoint(center, inner, (first+second)/2)
+			case maze.POLAR_CW:
+				sideMiddle = polarPoint(center, inner+ringHeight/2, second)
+			case maze.POLAR_CCW:
+				sideMiddle = polarPoint(center, inner+ringHeight/2, first)
+			default:
+				outward := float64(len(cell.OneWay) - maze.POLAR_OUT)
+				sideMiddle = polarPoint(center, outer, first+(second-first)*(float64(side-maze.POLAR_OUT)+0.5)/outward)
+			}
+			arrows.WriteString(svgArrow(cellCenter, sideMiddle))
+		}
+
+		shapes.WriteString(`<path id="c` + strconv.Itoa(index) + `" class="` + cellClass(cell) + `" d="M` +
+			svgPoint(polarPoint(center, inner, first)) + " L" + svgPoint(polarPoint(center, outer, first)) +
+			polarArc(center, outer, second, true) + " L" + svgPoint(polarPoint(center, inner, second)) +
+			polarArc(center, inner, first, false) + ` Z"/>` + "\n")
+
+		// Walls shared with a neighbor are only drawn from the outer or clockwise node, so they are only drawn once
+		if cell.Walls[maze.POLAR_IN] {
+			walls.WriteString("M" + svgPoint(polarPoint(center, inner, first)) + polarArc(center, inner, second, true) + " ")
+		}
+		if cell.Walls[maze.POLAR_CCW] {
+			walls.WriteString("M" + svgPoint(polarPoint(center, inner, first)) + " L" + svgPoint(polarPoint(center, outer, first)) + " ")
+		}
+		if cell.Row == rings-1 {
+			walls.WriteString("M" + svgPoint(polarPoint(center, outer, first)) + polarArc(center, outer, second, true) + " ")
+		}
+	}
+
+	return svg(2*center+2, 2*center+2, shapes.String(), walls.String(), arrows.String())
+}
+
+// svg wraps the shapes of every node, the path of every wall and the arrows of one-way passages into an svg that fits the page
+func svg(viewWidth float64, viewHeight float64, shapes string, walls string, arrows string) template.HTML {
+	return template.HTML(`<svg id="maze" class="maze-svg" xmlns="http://www.w3.org/2000/svg" viewBox="-1 -1 ` +
+		svgNumber(viewWidth) + " " + svgNumber(viewHeight) + `">` + "\n" +
+		shapes +
+		`<path class="walls" d="` + walls + `"/>` + "\n" +
+		`<path class="arrows" d="` + arrows + `"/>` + "\n</svg>")
+}
+
+// Half the length of an arrow in svg units
+const arrowSize = 1.5
+
+// svgArrow returns a path for a triangle centered on to, pointing away from from.
+func svgArrow(from []float64, to []float64) string {
+	dx, dy := to[0]-from[0], to[1]-from[1]
+	length := math.Hypot(dx, dy)
+	if length == 0 {
+		return ""
+	}
+	dx, dy = dx/length*arrowSize, dy/length*arrowSize
+	return "M" + svgPoint([]float64{to[0] + dx, to[1] + dy}) +
+		" L" + svgPoint([]float64{to[0] - dx - dy, to[1] - dy + dx}) +
+		" L" + svgPoint([]float64{to[0] - dx + dy, to[1] - dy - dx}) + " Z "
+}
+
+// midpoint returns the average of points
+func midpoint(points ...[]float64) []float64 {
+	sum := []float64{0, 0}
+	for _, point := range points {
+		sum[0] += point[0]
+		sum[1] += point[1]
+	}
+	return []float64{sum[0] / float64(len(points)), sum[1] / float64(len(points))}
+}
+
+// cellClass returns the css class of the shape of a node
+func cellClass(cell maze.MCell) string {
+	class := "cell " + terrainClass(cell.Terrain)
+	if cell.Val == maze.NODE_GOAL {
+		class += "c-goal"
+	}
+	return strings.TrimSpace(class)
+}
+
+func svgNumber(f float64) string {
+	return strconv.FormatFloat(f, 'f', 1, 64)
+}
+
+func svgPoint(point []float64) string {
+	return svgNumber(point[0]) + "," + svgNumber(point[1])
+}
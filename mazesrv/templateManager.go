@@ -9,22 +9,38 @@ import (
 type TemplateData struct {
 	// Template data structs must have exported names so the template Executer can read them.
 
-	// MStyles contains the CSS Style text for every node
-	MStyles [][]template.CSS
+	// MStyles contains the CSS Style text for every node on every level, if the nodes are squares
+	MStyles [][][]template.CSS
+	// LevelHeight is the number of rows in each level
+	LevelHeight template.JS
+	// MSvg contains the drawing of the maze, if the nodes are any other shape
+	MSvg template.HTML
 	// MPath contains the first-executed searching path
 	MPath template.JS
 	// MBestPath contains the second-executed solution path
 	MBestPath template.JS
+	// MPortals contains both ends of every portal, which are marked in matching colors
+	MPortals template.JS
+	// MDivisions contains the walls added by recursive division, which are animated before any paths
+	MDivisions template.JS
 	// TickSpeed determines how fast each node updates when drawing paths
 	TickSpeed template.JS
 	// PathRepeats determines the number of nodes updated for each tick when drawing paths
 	PathRepeats template.JS
 	// FormData allows for user inputted form data to reappear on the webpage
 	FormData template.JS
+	// Carved is the number of walls removed to connect the start to the goal
+	Carved int
+	// Cost is the cost of walking the solution, or 0 if there is no terrain and the solver ignores costs, or the solver is SOLVE_DEAD_END_FILL
+	Cost int
 }
 
 func toStyle(node maze.MNode) template.CSS {
-	out := ""
+	// Masked nodes are blank, and their neighbors draw the walls between them
+	if node.Masked {
+		return "masked"
+	}
+	out := terrainClass(node.Terrain)
 	// assumes the maze is empty to start, except for solution.
 	if node.Val == 3 {
 		out += "c-goal "
@@ -41,9 +57,47 @@ func toStyle(node maze.MNode) template.CSS {
 	if node.Left {
 		out += "b-l "
 	}
+	if node.UnderVertical {
+		out += "u-v "
+	}
+	if node.UnderHorizontal {
+		out += "u-h "
+	}
+	if node.StairsUp {
+		out += "st-u "
+	}
+	if node.StairsDown {
+		out += "st-d "
+	}
+	// Arrows show the sides a one-way passage leads out of
+	if node.OneWayUp == maze.ONEWAY_OUT {
+		out += "ow-u "
+	}
+	if node.OneWayDown == maze.ONEWAY_OUT {
+		out += "ow-d "
+	}
+	if node.OneWayRight == maze.ONEWAY_OUT {
+		out += "ow-r "
+	}
+	if node.OneWayLeft == maze.ONEWAY_OUT {
+		out += "ow-l "
+	}
 	return template.CSS(out)
 }
 
+// terrainClass returns the css class that colors a node by its terrain, followed by a space
+func terrainClass(terrain int) string {
+	switch terrain {
+	case maze.TERRAIN_ROAD:
+		return "t-road "
+	case maze.TERRAIN_MUD:
+		return "t-mud "
+	case maze.TERRAIN_WATER:
+		return "t-water "
+	}
+	return ""
+}
+
 func mazeSliceToStyle(mazeVals *[][]maze.MNode) [][]template.CSS {
 	mazeStyles := make([][]template.CSS, len(*mazeVals))
 	for row := range *mazeVals {
@@ -56,15 +110,30 @@ func mazeSliceToStyle(mazeVals *[][]maze.MNode) [][]template.CSS {
 	return mazeStyles
 }
 
-func pathToJs(mazeWidth int, path *[]int) template.JS {
+// stepFormat converts the index of a node into the js value the webpage uses to find it
+type stepFormat func(index int) string
+
+// squareStep finds square nodes in the table by [row, col]
+func squareStep(mazeWidth int) stepFormat {
+	return func(index int) string {
+		row, col := maze.GetSquareCoords(index, mazeWidth)
+		return "[" + strconv.Itoa(row) + ", " + strconv.Itoa(col) + "]"
+	}
+}
+
+// cellStep finds svg nodes by index
+func cellStep(index int) string {
+	return strconv.Itoa(index)
+}
+
+func pathToJs(format stepFormat, path *[]int) template.JS {
 	if len(*path) == 0 {
 		return "[]"
 	}
 
 	out := "["
 	for i := 0; i < len(*path); i++ {
-		row, col := maze.GetSquareCoords((*path)[i], mazeWidth)
-		out += "[" + strconv.Itoa(row) + ", " + strconv.Itoa(col) + "], "
+		out += format((*path)[i]) + ", "
 	}
 
 	// Cut off trailing comma
@@ -73,10 +142,14 @@ func pathToJs(mazeWidth int, path *[]int) template.JS {
 	return template.JS(out)
 }
 
-func pathsToJs(mazeWidth int, paths *[][]int) template.JS {
+func pathsToJs(format stepFormat, paths *[][]int) template.JS {
+	if len(*paths) == 0 {
+		return "[]"
+	}
+
 	out := template.JS("[")
 	for _, path := range *paths {
-		out += pathToJs(mazeWidth, &path)
+		out += pathToJs(format, &path)
 		out += ", "
 	}
 	// Cut off trailing comma
@@ -85,19 +158,81 @@ func pathsToJs(mazeWidth int, paths *[][]int) template.JS {
 	return out
 }
 
+// divisionsToJs converts the walls recorded by recursive division into a js array of
+// [orientation, row, col, length, gap] arrays.
+func divisionsToJs(divisions [][]int) template.JS {
+	out := "["
+	for i, d := range divisions {
+		if i > 0 {
+			out += ", "
+		}
+		out += "["
+		for j, v := range d {
+			if j > 0 {
+				out += ", "
+			}
+			out += strconv.Itoa(v)
+		}
+		out += "]"
+	}
+	out += "]"
+	return template.JS(out)
+}
+
 func fillTemplateData(in *MazeInputs) (*TemplateData, error) {
-	m, p, b, err := maze.MakeSolveMaze(in.width, in.height, in.density, in.genAlg, in.solveAlg, in.startIndex)
+	portalPairs, err := maze.ParsePortalPairs(in.portalPairs)
+	if err != nil {
+		return nil, err
+	}
+	res, err := maze.MakeSolveMaze(in.width, in.height, in.genAlg, maze.GenOptions{
+		Density:     in.density,
+		Bias:        in.bias,
+		Policy:      in.policy,
+		Braid:       in.braid,
+		Solvable:    in.solvable,
+		Shape:       in.shape,
+		Levels:      in.levels,
+		Wrap:        in.wrap,
+		Weave:       in.weave,
+		Mask:        in.maskNodes,
+		PortalPairs: portalPairs,
+		Portals:     in.portals,
+		OneWay:      in.oneWay,
+		Terrain:     in.terrain,
+		Heuristic:   in.heuristic,
+	}, in.solveAlg, in.startIndex)
 	if err != nil {
 		return nil, err
 	}
 
 	tplData := TemplateData{
-		MStyles:     mazeSliceToStyle(m),
-		MPath:       pathsToJs(in.width, p),
-		MBestPath:   pathToJs(in.width, b),
+		LevelHeight: template.JS(strconv.Itoa(in.height)),
+		MDivisions:  divisionsToJs(res.Divisions),
 		TickSpeed:   template.JS(strconv.Itoa(in.tickSpeed)),
 		PathRepeats: template.JS(strconv.Itoa(in.repeats)),
 		FormData:    template.JS(in.getFormData()),
+		Carved:      res.Carved,
+	}
+	// Without terrain, the cost is just the number of steps, which only matters to a solver that finds the cheapest path
+	if in.terrain > 0 || in.solveAlg == maze.SOLVE_DIJKSTRA {
+		tplData.Cost = res.Cost
+	}
+	// Square nodes are drawn with a table, and every other shape is drawn with svg
+	var format stepFormat
+	if res.Levels != nil {
+		for _, level := range res.Levels {
+			tplData.MStyles = append(tplData.MStyles, mazeSliceToStyle(level))
+		}
+		format = squareStep(in.width)
+	} else if res.Shape == maze.SHAPE_SQUARE {
+		tplData.MStyles = [][][]template.CSS{mazeSliceToStyle(res.Nodes)}
+		format = squareStep(in.width)
+	} else {
+		tplData.MSvg = cellsToSvg(res.Shape, in.width, in.height, res.Cells)
+		format = cellStep
 	}
+	tplData.MPath = pathsToJs(format, res.Paths)
+	tplData.MBestPath = pathToJs(format, res.Best)
+	tplData.MPortals = pathsToJs(format, &res.Portals)
 	return &tplData, nil
 }
@@ -1,6 +1,8 @@
 package mazesrv
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"go-mazes/maze"
 	"html/template"
@@ -8,6 +10,7 @@ import (
 	"math"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -22,6 +25,28 @@ type MazeInputs struct {
 	solveAlg   string
 	genAlg     string
 	startIndex int
+	bias       string
+	policy     string
+	braid      int
+	solvable   bool
+	shape      string
+	levels     int
+	wrap       string
+	weave      int
+	// mask is ASCII art of the nodes to leave out of the maze (see maze.ParseMask), and maskPNG is the same as a PNG image.
+	// If either is set, it is parsed into maskNodes by applyMask.
+	mask      string
+	maskPNG   []byte
+	maskNodes [][]bool
+	// portals is the number of random portals, and portalPairs lists more portals like "12-340,55-600"
+	portals     int
+	portalPairs string
+	// oneWay is the percent of passages that can only be passed in one direction
+	oneWay int
+	// terrain is the percent of nodes covered by road, mud and water
+	terrain int
+	// heuristic is the HEURISTIC_ used by A*
+	heuristic string
 }
 
 // fix corrects to default if a value out of a reasonable range.
@@ -45,35 +70,178 @@ func (in *MazeInputs) fix() {
 	if in.density <= 0 {
 		in.density = 15
 	}
-	// XXX Make sure my math is correct for both bounds
-	if in.startIndex < 0 || in.startIndex > ((in.width*in.height)-1) {
-		in.startIndex = 0
-	}
-	if in.solveAlg != maze.SOLVE_BFS_MULTI && in.solveAlg != maze.SOLVE_BFS_SINGLE && in.solveAlg != maze.SOLVE_DFS_MULTI {
+	if !validSolveAlg(in.solveAlg) {
 		in.solveAlg = maze.SOLVE_BFS_MULTI
 	}
-	if in.genAlg != maze.GEN_DFS && in.genAlg != maze.GEN_RAND && in.genAlg != maze.GEN_NONE {
+	if !validGenAlg(in.genAlg) {
+		in.genAlg = maze.GEN_DFS
+	}
+	if in.bias != maze.BIAS_NE && in.bias != maze.BIAS_NW && in.bias != maze.BIAS_SE && in.bias != maze.BIAS_SW {
+		in.bias = maze.BIAS_NE
+	}
+	if maze.CheckGrowPolicy(in.policy) != nil {
+		in.policy = maze.POLICY_NEWEST
+	}
+	if in.braid < 0 || in.braid > 100 {
+		in.braid = 0
+	}
+	if in.weave < 0 || in.weave > 100 {
+		in.weave = 0
+	}
+	if in.portals < 0 || in.portals > 50 {
+		in.portals = 0
+	}
+	if in.oneWay < 0 || in.oneWay > 100 {
+		in.oneWay = 0
+	}
+	if in.terrain < 0 || in.terrain > 100 {
+		in.terrain = 0
+	}
+	if in.levels < 1 || in.levels > 10 {
+		in.levels = 1
+	}
+	// Levels don't make the limit on the number of nodes any bigger, which keeps the recursion of the solvers from running out of stack
+	if in.width*in.height*in.levels > 1000*1000 {
+		in.levels = 1000 * 1000 / (in.width * in.height)
+	}
+	switch in.wrap {
+	case maze.WRAP_NONE, maze.WRAP_HORIZONTAL, maze.WRAP_VERTICAL, maze.WRAP_BOTH:
+	default:
+		in.wrap = maze.WRAP_NONE
+	}
+	switch in.shape {
+	case maze.SHAPE_SQUARE, maze.SHAPE_TRIANGLE, maze.SHAPE_HEX, maze.SHAPE_POLAR:
+	default:
+		in.shape = maze.SHAPE_SQUARE
+	}
+	// Only square nodes can have levels or wrap
+	if in.shape != maze.SHAPE_SQUARE {
+		in.levels = 1
+		in.wrap = maze.WRAP_NONE
+	}
+	// Masks only work with one level of rows and columns, so they turn polar mazes into square ones
+	hasMask := len(in.maskPNG) > 0 || strings.TrimSpace(in.mask) != ""
+	if hasMask {
+		in.levels = 1
+		if in.shape == maze.SHAPE_POLAR {
+			in.shape = maze.SHAPE_SQUARE
+		}
+	}
+	// Polar mazes have about pi*height*height nodes, whatever the width
+	for in.shape == maze.SHAPE_POLAR && maze.NodeCount(in.width, in.height, in.shape, in.levels) > 1000*1000 {
+		in.height--
+	}
+	if in.startIndex < 0 || in.startIndex >= maze.NodeCount(in.width, in.height, in.shape, in.levels) {
+		in.startIndex = 0
+	}
+	// Some generators only work on one level of rows and columns of square nodes that don't wrap or have a mask
+	if (in.shape != maze.SHAPE_SQUARE || in.levels > 1 || in.wrap != maze.WRAP_NONE || hasMask) && maze.SquareOnly(in.genAlg) {
 		in.genAlg = maze.GEN_DFS
 	}
+	switch in.heuristic {
+	case maze.HEURISTIC_MANHATTAN, maze.HEURISTIC_EUCLIDEAN, maze.HEURISTIC_ZERO:
+	default:
+		in.heuristic = maze.HEURISTIC_MANHATTAN
+	}
 }
 
+// applyMask parses the mask, if there is one, and makes the maze the size of it.
+// If the start is masked, it is moved to the first node that isn't.
+func (in *MazeInputs) applyMask() error {
+	var err error
+	switch {
+	case len(in.maskPNG) > 0:
+		in.maskNodes, err = maze.ParsePNGMask(bytes.NewReader(in.maskPNG))
+	case strings.TrimSpace(in.mask) != "":
+		in.maskNodes, err = maze.ParseMask(in.mask)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(in.maskNodes) > 1000 || len(in.maskNodes[0]) > 1000 {
+		return mkErr("mask is too big")
+	}
+
+	in.height = len(in.maskNodes)
+	in.width = len(in.maskNodes[0])
+	if in.startIndex >= in.width*in.height {
+		in.startIndex = 0
+	}
+	for in.startIndex < in.width*in.height-1 && in.maskNodes[in.startIndex/in.width][in.startIndex%in.width] {
+		in.startIndex++
+	}
+	return nil
+}
+
+// validGenAlg returns true if alg is one of the generation algorithms offered by the webpage.
+func validGenAlg(alg string) bool {
+	switch alg {
+	case maze.GEN_DFS, maze.GEN_RAND, maze.GEN_NONE, maze.GEN_PRIM, maze.GEN_KRUSKAL,
+		maze.GEN_WILSON, maze.GEN_ALDOUS_BRODER, maze.GEN_ELLER, maze.GEN_DIVISION, maze.GEN_BINARY_TREE,
+		maze.GEN_SIDEWINDER, maze.GEN_GROWING_TREE, maze.GEN_HUNT_KILL:
+		return true
+	}
+	return false
+}
+
+// validSolveAlg returns true if alg is one of the solving algorithms offered by the webpage.
+func validSolveAlg(alg string) bool {
+	switch alg {
+	case maze.SOLVE_BFS_SINGLE, maze.SOLVE_BFS_MULTI, maze.SOLVE_DFS_MULTI, maze.SOLVE_BFS_BIDIRECTIONAL,
+		maze.SOLVE_ASTAR, maze.SOLVE_DIJKSTRA, maze.SOLVE_WALL_FOLLOWER_LEFT, maze.SOLVE_WALL_FOLLOWER_RIGHT,
+		maze.SOLVE_DEAD_END_FILL:
+		return true
+	}
+	return false
+}
+
+// getFormData returns a js object that maps the id of each form input to its value.
 func (in *MazeInputs) getFormData() string {
-	// I know this is gross, sorry.
-	return "[\"" + in.genAlg + "\", \"" + in.solveAlg + "\", \"" + strconv.Itoa(in.width) + "\", \"" + strconv.Itoa(in.height) + "\", \"" + strconv.Itoa(in.tickSpeed) + "\", \"" + strconv.Itoa(in.repeats) + "\", \"" + strconv.Itoa(in.density) + "\"]"
+	formData, _ := json.Marshal(map[string]string{
+		"generateAlgorithm": in.genAlg,
+		"solveAlgorithm":    in.solveAlg,
+		"width":             strconv.Itoa(in.width),
+		"height":            strconv.Itoa(in.height),
+		"tickSpeed":         strconv.Itoa(in.tickSpeed),
+		"repeats":           strconv.Itoa(in.repeats),
+		"density":           strconv.Itoa(in.density),
+		"bias":              in.bias,
+		"policy":            in.policy,
+		"braid":             strconv.Itoa(in.braid),
+		"solvable":          strconv.FormatBool(in.solvable),
+		"shape":             in.shape,
+		"levels":            strconv.Itoa(in.levels),
+		"wrap":              in.wrap,
+		"weave":             strconv.Itoa(in.weave),
+		"mask":              in.mask,
+		"startIndex":        strconv.Itoa(in.startIndex),
+		"portals":           strconv.Itoa(in.portals),
+		"portalPairs":       in.portalPairs,
+		"oneWay":            strconv.Itoa(in.oneWay),
+		"terrain":           strconv.Itoa(in.terrain),
+		"heuristic":         in.heuristic,
+	})
+	return string(formData)
 }
 
-func makeMaze(in *MazeInputs, wr io.Writer) error {
+// makeMaze writes the maze webpage to wr and returns the data it was filled with.
+func makeMaze(in *MazeInputs, wr io.Writer) (*TemplateData, error) {
 	in.fix()
+	if err := in.applyMask(); err != nil {
+		return nil, err
+	}
 
 	timeStart := time.Now()
 	tplData, err := fillTemplateData(in)
 	timeEnd := time.Now()
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 	printTime(timeStart, timeEnd)
-	return tpl.Execute(wr, tplData)
+	return tplData, tpl.Execute(wr, tplData)
 }
 
 // MakeMazeResponse converts a http request into a http response
@@ -101,20 +269,71 @@ func MakeMazeResponse(wr http.ResponseWriter, rd *http.Request) {
 	}
 	sa := rd.URL.Query().Get("solveAlgorithm")
 	ga := rd.URL.Query().Get("generateAlgorithm")
+	b := rd.URL.Query().Get("bias")
+	p := rd.URL.Query().Get("policy")
+	br, err := strconv.Atoi(rd.URL.Query().Get("braid"))
+	if err != nil {
+		br = -1
+	}
+	// Checkboxes are only sent when they are checked
+	sv := rd.URL.Query().Get("solvable") != ""
+	sh := rd.URL.Query().Get("shape")
+	l, err := strconv.Atoi(rd.URL.Query().Get("levels"))
+	if err != nil {
+		l = -1
+	}
+	wp := rd.URL.Query().Get("wrap")
+	wv, err := strconv.Atoi(rd.URL.Query().Get("weave"))
+	if err != nil {
+		wv = -1
+	}
+	mk := rd.URL.Query().Get("mask")
+	pt, err := strconv.Atoi(rd.URL.Query().Get("portals"))
+	if err != nil {
+		pt = -1
+	}
+	pp := rd.URL.Query().Get("portalPairs")
+	ow, err := strconv.Atoi(rd.URL.Query().Get("oneWay"))
+	if err != nil {
+		ow = -1
+	}
+	tr, err := strconv.Atoi(rd.URL.Query().Get("terrain"))
+	if err != nil {
+		tr = -1
+	}
+	hr := rd.URL.Query().Get("heuristic")
+	si, err := strconv.Atoi(rd.URL.Query().Get("startIndex"))
+	if err != nil {
+		si = -1
+	}
 
 	// Calculate and display maze results
 	// XXX TODO Sometimes there are visual glitches in the maze display
 	in := MazeInputs{
-		width:      w,
-		height:     h,
-		tickSpeed:  ts,
-		repeats:    r,
-		density:    d,
-		solveAlg:   sa,
-		genAlg:     ga,
-		startIndex: 0,
-	}
-	err = makeMaze(&in, wr)
+		width:       w,
+		height:      h,
+		tickSpeed:   ts,
+		repeats:     r,
+		density:     d,
+		solveAlg:    sa,
+		genAlg:      ga,
+		startIndex:  si,
+		bias:        b,
+		policy:      p,
+		braid:       br,
+		solvable:    sv,
+		shape:       sh,
+		levels:      l,
+		wrap:        wp,
+		weave:       wv,
+		mask:        mk,
+		portals:     pt,
+		portalPairs: pp,
+		oneWay:      ow,
+		terrain:     tr,
+		heuristic:   hr,
+	}
+	_, err = makeMaze(&in, wr)
 	if err != nil {
 		fmt.Printf("Maze error: %v\n", err)
 	}